@@ -10,12 +10,23 @@ import (
 
 func main() {
 	zlog.Init()
+	if len(os.Args) > 1 {
+		// Commands may write their output to stdout.
+		zlog.Logger = zlog.Logger.Output(os.Stderr)
+	}
 
 	cfg, err := config.MustLoad()
 	if err != nil {
 		zlog.Logger.Fatal().Err(err).Msg("Failed to load config")
 	}
 
+	if len(os.Args) > 1 {
+		if err := app.RunCommand(cfg, &zlog.Logger, os.Args[1:]); err != nil {
+			zlog.Logger.Fatal().Err(err).Msg("Command failed")
+		}
+		os.Exit(0)
+	}
+
 	application, err := app.NewApp(cfg, &zlog.Logger)
 	if err != nil {
 		zlog.Logger.Fatal().Err(err).Msg("Failed to create application")
@@ -2,20 +2,29 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"url-shortener-wb/internal/config"
+	"url-shortener-wb/internal/geoip"
 	"url-shortener-wb/internal/http-server/handler"
 	"url-shortener-wb/internal/http-server/middleware"
 	"url-shortener-wb/internal/http-server/router"
+	"url-shortener-wb/internal/metadata"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/pages"
+	"url-shortener-wb/internal/qr"
 	analytics_postgres "url-shortener-wb/internal/repository/analytics/postgres"
 	"url-shortener-wb/internal/repository/cache/redis"
 	url_postgres "url-shortener-wb/internal/repository/url/postgres"
+	"url-shortener-wb/internal/signing"
+	"url-shortener-wb/internal/tracing"
 	"url-shortener-wb/internal/usecase"
 
 	"github.com/wb-go/wbf/dbpg"
@@ -23,15 +32,32 @@ import (
 )
 
 type App struct {
-	cfg    *config.Config
-	server *http.Server
-	logger *zlog.Zerolog
-	db     *dbpg.DB
+	cfg       *config.Config
+	server    *http.Server
+	logger    *zlog.Zerolog
+	db        *dbpg.DB
+	analytics backgroundWorker
+	metadata  backgroundWorker
+	checker   backgroundWorker
+	health    *handler.HealthHandler
+	geo       *geoip.Locator
+
+	shutdownTracing func(context.Context) error
+}
+
+type backgroundWorker interface {
+	Start()
+	Stop(ctx context.Context) error
 }
 
 func NewApp(cfg *config.Config, logger *zlog.Zerolog) (*App, error) {
 	retries := cfg.DefaultRetryStrategy()
 
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init tracing: %w", err)
+	}
+
 	dbOpts := &dbpg.Options{
 		MaxOpenConns:    cfg.DB.MaxOpenConns,
 		MaxIdleConns:    cfg.DB.MaxIdleConns,
@@ -42,24 +68,104 @@ func NewApp(cfg *config.Config, logger *zlog.Zerolog) (*App, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	metrics.RegisterDB(db)
+
+	geo, err := geoip.Open(cfg.GeoIP.DBPath)
+	if err != nil {
+		return nil, err
+	}
 
 	cache := redis.NewRedisCache(cfg, retries)
 	urlRepo := url_postgres.NewURLRepository(db, retries)
 	analyticsRepo := analytics_postgres.NewAnalyticsRepository(db, urlRepo, retries)
 
-	analyticsUsecase := usecase.NewAnalyticsUsecase(analyticsRepo, urlRepo)
-	urlUsecase := usecase.NewURLUsecase(urlRepo, cache, logger)
+	analyticsUsecase := usecase.NewAnalyticsUsecase(
+		analyticsRepo,
+		urlRepo,
+		logger,
+		cfg.Clicks.QueueSize,
+		cfg.Clicks.Workers,
+		cfg.Clicks.CountFlushInterval,
+	)
+	passwordSecret, err := passwordSecret(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := signing.NewKeyring(cfg.Signing.Keys, cfg.Signing.ActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	fetcher := metadata.NewFetcher(metadata.Options{
+		Timeout:      cfg.Metadata.Timeout,
+		MaxBytes:     cfg.Metadata.MaxBytes,
+		AllowPrivate: cfg.Metadata.AllowPrivate,
+	})
+	metadataUsecase := usecase.NewMetadataUsecase(
+		urlRepo,
+		fetcher,
+		cfg.Metadata.Timeout,
+		logger,
+		cfg.Metadata.QueueSize,
+		cfg.Metadata.Workers,
+	)
+
+	urlUsecase := usecase.NewURLUsecase(urlRepo, cache, geo, usecase.SigningOptions{
+		Signer: keyring,
+		MaxTTL: cfg.Signing.MaxTTL,
+	}, usecase.PasswordOptions{
+		Secret:        passwordSecret,
+		TokenTTL:      cfg.Password.CookieTTL,
+		MaxAttempts:   cfg.Password.MaxAttempts,
+		AttemptWindow: cfg.Password.AttemptWindow,
+	}, usecase.PreviewOptions{
+		Fetcher:  fetcher,
+		Queue:    metadataUsecase,
+		CacheTTL: cfg.Metadata.CacheTTL,
+		Timeout:  cfg.Metadata.Timeout,
+	}, logger)
+
+	targetChecker := usecase.NewTargetChecker(urlRepo, cache, usecase.TargetCheckOptions{
+		Interval:         cfg.TargetCheck.Interval,
+		Timeout:          cfg.TargetCheck.Timeout,
+		FailureThreshold: cfg.TargetCheck.FailureThreshold,
+		BatchSize:        cfg.TargetCheck.BatchSize,
+		Client:           metadata.NewClient(cfg.TargetCheck.Timeout, cfg.TargetCheck.AllowPrivate),
+	}, logger)
+
+	pageRenderer, err := pages.New(cfg.Pages.TemplatesDir, cfg.Pages.Brand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load page templates: %w", err)
+	}
+
+	qrEncoder, err := qr.NewEncoder(cfg.QR.LogoPath)
+	if err != nil {
+		return nil, err
+	}
 
 	analyticsHandler := handler.NewAnalyticsHandler(analyticsUsecase, logger)
-	urlHandler := handler.NewURLHandler(urlUsecase, analyticsUsecase, logger)
+	urlHandler := handler.NewURLHandler(urlUsecase, analyticsUsecase, pageRenderer, qrEncoder, logger)
+
+	healthHandler := handler.NewHealthHandler(dependencyChecks(db, cache), cfg.Server.HealthTimeout, logger)
 
 	h := &router.Handler{
 		UrlH:       urlHandler,
 		AnalyticsH: analyticsHandler,
+		HealthH:    healthHandler,
 	}
 
 	mux := router.SetupRouter(h)
-	muxWM := middleware.LoggingMiddleware(mux)
+	accessLog := middleware.LoggingMiddleware(middleware.LoggingOptions{
+		SampleRate:   cfg.Log.AccessSampleRate,
+		RedactParams: cfg.Log.RedactParams,
+	})
+	requestID := middleware.RequestIDMiddleware(logger)
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trusted proxies: %w", err)
+	}
+	realIP := middleware.RealIPMiddleware(trustedProxies)
+	muxWM := middleware.TracingMiddleware(realIP(requestID(accessLog(mux))))
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Addr,
@@ -70,10 +176,17 @@ func NewApp(cfg *config.Config, logger *zlog.Zerolog) (*App, error) {
 	}
 
 	return &App{
-		cfg:    cfg,
-		server: server,
-		logger: logger,
-		db:     db,
+		cfg:       cfg,
+		server:    server,
+		logger:    logger,
+		db:        db,
+		analytics: analyticsUsecase,
+		metadata:  metadataUsecase,
+		checker:   targetChecker,
+		health:    healthHandler,
+		geo:       geo,
+
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
@@ -85,6 +198,10 @@ func (a *App) Run() error {
 
 	go a.handleSignals(cancel)
 
+	a.analytics.Start()
+	a.metadata.Start()
+	a.checker.Start()
+
 	serverErr := make(chan error, 1)
 	go func() {
 		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -99,6 +216,12 @@ func (a *App) Run() error {
 	case <-ctx.Done():
 		a.logger.Info().Msg("Shutting down server")
 
+		a.health.SetShuttingDown()
+		if a.cfg.Server.ShutdownDelay > 0 {
+			a.logger.Info().Dur("delay", a.cfg.Server.ShutdownDelay).Msg("Waiting for readiness to propagate")
+			time.Sleep(a.cfg.Server.ShutdownDelay)
+		}
+
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
 		defer shutdownCancel()
 
@@ -106,12 +229,43 @@ func (a *App) Run() error {
 			a.logger.Error().Err(err).Msg("Server shutdown failed")
 		}
 
+		if err := a.checker.Stop(shutdownCtx); err != nil {
+			a.logger.Error().Err(err).Msg("Target checker shutdown failed")
+		}
+
+		if err := a.metadata.Stop(shutdownCtx); err != nil {
+			a.logger.Error().Err(err).Msg("Metadata queue shutdown failed")
+		}
+
+		if err := a.analytics.Stop(shutdownCtx); err != nil {
+			a.logger.Error().Err(err).Msg("Click pipeline shutdown failed")
+		}
+
+		if err := a.shutdownTracing(shutdownCtx); err != nil {
+			a.logger.Error().Err(err).Msg("Tracing shutdown failed")
+		}
+
 		a.db.Master.Close()
+		a.geo.Close()
 		a.logger.Info().Msg("Server stopped gracefully")
 		return nil
 	}
 }
 
+func dependencyChecks(db *dbpg.DB, cache *redis.RedisCache) []handler.DependencyCheck {
+	checks := []handler.DependencyCheck{
+		{Name: "postgres_master", Check: db.Master.PingContext},
+	}
+	for i, slave := range db.Slaves {
+		checks = append(checks, handler.DependencyCheck{
+			Name:  fmt.Sprintf("postgres_slave_%d", i),
+			Check: slave.PingContext,
+		})
+	}
+	checks = append(checks, handler.DependencyCheck{Name: "redis", Check: cache.Ping})
+	return checks
+}
+
 func (a *App) handleSignals(cancel context.CancelFunc) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -120,3 +274,18 @@ func (a *App) handleSignals(cancel context.CancelFunc) {
 	a.logger.Info().Str("signal", sig.String()).Msg("Received signal")
 	cancel()
 }
+
+// passwordSecret returns the configured unlock cookie secret, or a random
+// one when none is set. A random secret logs visitors out on restart and
+// does not work across several instances.
+func passwordSecret(cfg *config.Config, logger *zlog.Zerolog) ([]byte, error) {
+	if cfg.Password.CookieSecret != "" {
+		return []byte(cfg.Password.CookieSecret), nil
+	}
+	logger.Warn().Msg("PASSWORD_COOKIE_SECRET is not set, using a random secret")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate password cookie secret: %w", err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"url-shortener-wb/internal/catalog"
+	"url-shortener-wb/internal/config"
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/repository/cache/redis"
+	url_postgres "url-shortener-wb/internal/repository/url/postgres"
+	"url-shortener-wb/internal/usecase"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const commandUsage = `usage:
+  url-shortener                             run the server
+  url-shortener import [-dry-run] FILE.csv  import links, "-" reads stdin
+  url-shortener export [-o FILE.csv]        export links with click totals`
+
+// RunCommand runs a one-off catalog command instead of the server.
+func RunCommand(cfg *config.Config, logger *zlog.Zerolog, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "import":
+		return runImport(ctx, cfg, logger, args[1:])
+	case "export":
+		return runExport(ctx, cfg, logger, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], commandUsage)
+	}
+}
+
+func runImport(ctx context.Context, cfg *config.Config, logger *zlog.Zerolog, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without creating links")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(commandUsage)
+	}
+
+	in := io.Reader(os.Stdin)
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open catalog: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	return withCatalogUsecase(cfg, logger, func(urlUsecase catalogUsecase) error {
+		report, err := catalog.Import(ctx, urlUsecase, in, *dryRun)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range report.Rows {
+			switch {
+			case row.Err != nil:
+				fmt.Printf("line %d: %v\n", row.Line, row.Err)
+			case !report.DryRun:
+				fmt.Printf("line %d: created %s\n", row.Line, row.Alias)
+			}
+		}
+		verb := "created"
+		if report.DryRun {
+			verb = "valid"
+		}
+		fmt.Printf("%d %s, %d failed\n", report.Succeeded, verb, report.Failed)
+
+		if report.Failed > 0 {
+			return fmt.Errorf("%d lines failed", report.Failed)
+		}
+		return nil
+	})
+}
+
+func runExport(ctx context.Context, cfg *config.Config, logger *zlog.Zerolog, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", "-", `output file, "-" for stdout`)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return withCatalogUsecase(cfg, logger, func(urlUsecase catalogUsecase) error {
+		return catalog.Export(ctx, urlUsecase, out)
+	})
+}
+
+type catalogUsecase interface {
+	catalog.Importer
+	catalog.Exporter
+}
+
+// skipMetadata drops metadata fetches: a one-off command would exit before
+// they finish, and previews fetch missing metadata on demand.
+type skipMetadata struct{}
+
+func (skipMetadata) FetchMetadata(context.Context, *domain.URL) {}
+
+// withCatalogUsecase runs fn with a link usecase backed by the configured
+// stores.
+func withCatalogUsecase(cfg *config.Config, logger *zlog.Zerolog, fn func(catalogUsecase) error) error {
+	retries := cfg.DefaultRetryStrategy()
+
+	db, err := dbpg.New(cfg.DBDSN(), cfg.DB.Slaves, &dbpg.Options{
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Master.Close()
+
+	cache := redis.NewRedisCache(cfg, retries)
+	urlRepo := url_postgres.NewURLRepository(db, retries)
+
+	urlUsecase := usecase.NewURLUsecase(urlRepo, cache, nil, usecase.SigningOptions{}, usecase.PasswordOptions{},
+		usecase.PreviewOptions{Queue: skipMetadata{}}, logger)
+	return fn(urlUsecase)
+}
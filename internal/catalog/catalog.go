@@ -0,0 +1,208 @@
+// Package catalog reads and writes the link catalog as CSV.
+//
+// A catalog has a header row naming its columns. Import requires url and
+// understands alias, tags (comma-separated) and expiry (RFC 3339 time or a
+// date, meaning the end of that day in UTC); other columns are ignored, so
+// exports of other services can be fed in as they are. Export writes the
+// same columns followed by clicks and created_at, so it can be imported
+// again.
+package catalog
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+)
+
+const (
+	ColumnURL       = "url"
+	ColumnAlias     = "alias"
+	ColumnTags      = "tags"
+	ColumnExpiry    = "expiry"
+	ColumnClicks    = "clicks"
+	ColumnCreatedAt = "created_at"
+)
+
+var (
+	ErrInvalidCSV = errors.New("invalid csv")
+	ErrInvalidRow = errors.New("invalid row")
+)
+
+// Row is one parsed catalog line; Err is set when the line itself is
+// malformed.
+type Row struct {
+	Line  int
+	Input domain.CreateURLInput
+	Err   error
+}
+
+// RowResult reports one line of an import. Alias is the created link, or
+// in a dry run the requested alias of a valid line.
+type RowResult struct {
+	Line  int
+	Alias string
+	Err   error
+}
+
+type Report struct {
+	DryRun bool
+	// Succeeded counts created lines, or valid ones in a dry run.
+	Succeeded int
+	Failed    int
+	Rows      []RowResult
+}
+
+// Read parses a catalog. It fails as a whole only when the file is not CSV
+// or lacks the url column; problems of single lines are reported in Row.Err.
+func Read(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: missing header row", ErrInvalidCSV)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	if _, ok := columns[ColumnURL]; !ok {
+		return nil, fmt.Errorf("%w: header has no %q column", ErrInvalidCSV, ColumnURL)
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := Row{Line: line, Input: domain.CreateURLInput{
+			OriginalURL: field(ColumnURL),
+			CustomAlias: field(ColumnAlias),
+			Tags:        splitTags(field(ColumnTags)),
+		}}
+		row.Input.ActiveUntil, row.Err = parseExpiry(field(ColumnExpiry))
+		rows = append(rows, row)
+	}
+}
+
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func parseExpiry(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: expiry %q is neither an RFC 3339 time nor a YYYY-MM-DD date", ErrInvalidRow, s)
+}
+
+// Import reads a catalog and creates its links, or with dryRun only
+// validates them.
+func Import(ctx context.Context, importer Importer, r io.Reader, dryRun bool) (*Report, error) {
+	rows, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		inputs  []domain.CreateURLInput
+		indexes []int
+	)
+	report := &Report{DryRun: dryRun, Rows: make([]RowResult, len(rows))}
+	for i, row := range rows {
+		report.Rows[i] = RowResult{Line: row.Line, Err: row.Err}
+		if row.Err == nil {
+			inputs = append(inputs, row.Input)
+			indexes = append(indexes, i)
+		}
+	}
+
+	results, err := importer.ImportURLs(ctx, inputs, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	for k, res := range results {
+		report.Rows[indexes[k]].Alias = res.Alias
+		report.Rows[indexes[k]].Err = res.Err
+	}
+
+	for _, row := range report.Rows {
+		if row.Err != nil {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+	return report, nil
+}
+
+// Export writes the whole catalog to w.
+func Export(ctx context.Context, exporter Exporter, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		ColumnURL, ColumnAlias, ColumnTags, ColumnExpiry, ColumnClicks, ColumnCreatedAt,
+	}); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	err := exporter.ExportURLs(ctx, func(entry domain.CatalogEntry) error {
+		var expiry string
+		if !entry.URL.ActiveUntil.IsZero() {
+			expiry = entry.URL.ActiveUntil.UTC().Format(time.RFC3339)
+		}
+		return writer.Write([]string{
+			entry.URL.OriginalURL,
+			entry.URL.Alias,
+			strings.Join(entry.URL.Tags, ","),
+			expiry,
+			strconv.FormatInt(entry.Clicks, 10),
+			entry.URL.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export catalog: %w", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package catalog
+
+import (
+	"context"
+
+	"url-shortener-wb/internal/domain"
+)
+
+type Importer interface {
+	ImportURLs(ctx context.Context, inputs []domain.CreateURLInput, dryRun bool) ([]domain.BatchResult, error)
+}
+
+type Exporter interface {
+	ExportURLs(ctx context.Context, fn func(domain.CatalogEntry) error) error
+}
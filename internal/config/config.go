@@ -34,6 +34,67 @@ type Config struct {
 		WriteTimeout    time.Duration `env:"SERVER_WRITE_TIMEOUT" validate:"required"`
 		IdleTimeout     time.Duration `env:"SERVER_IDLE_TIMEOUT" validate:"required"`
 		ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" validate:"required"`
+		ShutdownDelay   time.Duration `env:"SERVER_SHUTDOWN_DELAY"`
+		HealthTimeout   time.Duration `env:"SERVER_HEALTH_TIMEOUT" env-default:"2s"`
+		// TrustedProxies lists the reverse proxies, as IPs or CIDR ranges,
+		// whose X-Forwarded-For is believed.
+		TrustedProxies []string `env:"SERVER_TRUSTED_PROXIES"`
+	}
+	GeoIP struct {
+		DBPath string `env:"GEOIP_DB_PATH"`
+	}
+	Log struct {
+		AccessSampleRate float64  `env:"LOG_ACCESS_SAMPLE_RATE" env-default:"1" validate:"gte=0,lte=1"`
+		RedactParams     []string `env:"LOG_REDACT_PARAMS" env-default:"token,access_token,api_key,key,password,sig,secret"`
+	}
+	Clicks struct {
+		QueueSize          int           `env:"CLICKS_QUEUE_SIZE" env-default:"1024"`
+		Workers            int           `env:"CLICKS_WORKERS" env-default:"4"`
+		CountFlushInterval time.Duration `env:"CLICKS_COUNT_FLUSH_INTERVAL" env-default:"5s"`
+	}
+	Password struct {
+		CookieSecret  string        `env:"PASSWORD_COOKIE_SECRET"`
+		CookieTTL     time.Duration `env:"PASSWORD_COOKIE_TTL" env-default:"1h"`
+		MaxAttempts   int           `env:"PASSWORD_MAX_ATTEMPTS" env-default:"5" validate:"gte=1"`
+		AttemptWindow time.Duration `env:"PASSWORD_ATTEMPT_WINDOW" env-default:"15m"`
+	}
+	Signing struct {
+		// Keys maps key IDs to secrets, e.g. "2026a:secret1,2025b:secret2".
+		Keys      map[string]string `env:"SIGNING_KEYS"`
+		ActiveKey string            `env:"SIGNING_ACTIVE_KEY"`
+		MaxTTL    time.Duration     `env:"SIGNING_MAX_TTL" env-default:"720h"`
+	}
+	Pages struct {
+		// TemplatesDir overrides the built-in HTML pages, see internal/pages.
+		TemplatesDir string `env:"PAGES_TEMPLATES_DIR"`
+		Brand        string `env:"PAGES_BRAND" env-default:"URL Shortener"`
+	}
+	QR struct {
+		// LogoPath is a PNG or JPEG placed in the centre of codes on request.
+		LogoPath string `env:"QR_LOGO_PATH"`
+	}
+	Metadata struct {
+		Timeout   time.Duration `env:"METADATA_TIMEOUT" env-default:"3s"`
+		MaxBytes  int64         `env:"METADATA_MAX_BYTES" env-default:"1048576" validate:"gte=1024"`
+		CacheTTL  time.Duration `env:"METADATA_CACHE_TTL" env-default:"1h"`
+		QueueSize int           `env:"METADATA_QUEUE_SIZE" env-default:"256"`
+		Workers   int           `env:"METADATA_WORKERS" env-default:"2"`
+		// AllowPrivate lets previews fetch local destinations in development.
+		AllowPrivate bool `env:"METADATA_ALLOW_PRIVATE"`
+	}
+	TargetCheck struct {
+		Interval         time.Duration `env:"TARGET_CHECK_INTERVAL" env-default:"5m"`
+		Timeout          time.Duration `env:"TARGET_CHECK_TIMEOUT" env-default:"5s"`
+		FailureThreshold int           `env:"TARGET_CHECK_FAILURE_THRESHOLD" env-default:"3" validate:"gte=1"`
+		BatchSize        int           `env:"TARGET_CHECK_BATCH_SIZE" env-default:"100" validate:"gte=1"`
+		// AllowPrivate lets probes reach local destinations in development.
+		AllowPrivate bool `env:"TARGET_CHECK_ALLOW_PRIVATE"`
+	}
+	Tracing struct {
+		Exporter    string  `env:"TRACING_EXPORTER" env-default:"none" validate:"oneof=none stdout otlp"`
+		Endpoint    string  `env:"TRACING_OTLP_ENDPOINT"`
+		ServiceName string  `env:"TRACING_SERVICE_NAME" env-default:"url-shortener"`
+		SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1" validate:"gte=0,lte=1"`
 	}
 	Retries struct {
 		Attempts int     `env:"RETRIES_ATTEMPTS" validate:"required"`
@@ -2,12 +2,24 @@ package domain
 
 import "time"
 
+// Channel is how a visitor reached a link.
+type Channel string
+
+const (
+	ChannelLink Channel = "link"
+	ChannelQR   Channel = "qr"
+)
+
 type Click struct {
-	ID        int64
-	URLID     int64
-	UserAgent string
-	IPAddress string
-	ClickedAt time.Time
+	ID          int64
+	URLID       int64
+	UserAgent   string
+	IPAddress   string
+	MatchedRule string
+	Locale      string
+	Variant     string
+	Channel     Channel
+	ClickedAt   time.Time
 }
 
 type AnalyticsReport struct {
@@ -15,5 +27,26 @@ type AnalyticsReport struct {
 	DailyStats     map[string]int
 	MonthlyStats   map[string]int
 	UserAgentStats map[string]int
+	RuleStats      map[string]int
+	LocaleStats    map[string]int
+	VariantStats   map[string]int
+	ChannelStats   map[string]int
 	Clicks         []Click
 }
+
+type CampaignLinkStats struct {
+	Alias       string
+	OriginalURL string
+	Source      string
+	Medium      string
+	Clicks      int
+}
+
+type CampaignReport struct {
+	Campaign    string
+	TotalClicks int
+	DailyStats  map[string]int
+	SourceStats map[string]int
+	MediumStats map[string]int
+	Links       []CampaignLinkStats
+}
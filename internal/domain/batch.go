@@ -0,0 +1,8 @@
+package domain
+
+// BatchResult is the outcome of one item of a batch create, reported at the
+// item's position in the request. Alias is set when the link was created.
+type BatchResult struct {
+	Alias string
+	Err   error
+}
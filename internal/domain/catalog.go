@@ -0,0 +1,7 @@
+package domain
+
+// CatalogEntry is a link as exported or listed, URL.Tags included.
+type CatalogEntry struct {
+	URL    *URL
+	Clicks int64
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Folder groups links in a tree; ParentID is 0 for top-level folders.
+type Folder struct {
+	ID       int64
+	ParentID int64
+	Name     string
+	// Path joins the names from the top level down, e.g. "Marketing/2026".
+	Path string
+	// Links is the number of links directly in the folder.
+	Links     int
+	CreatedAt time.Time
+}
+
+// UpdateFolderInput holds the editable folder fields; nil fields are left
+// as is. A ParentID of 0 moves the folder to the top level.
+type UpdateFolderInput struct {
+	Name     *string
+	ParentID *int64
+}
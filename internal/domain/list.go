@@ -0,0 +1,82 @@
+package domain
+
+import "time"
+
+// LinkStatus selects links by whether they currently redirect.
+type LinkStatus string
+
+const (
+	// StatusActive links redirect now.
+	StatusActive LinkStatus = "active"
+	// StatusScheduled links are enabled but their window has not opened.
+	StatusScheduled LinkStatus = "scheduled"
+	// StatusExpired links are past their window or click limit.
+	StatusExpired LinkStatus = "expired"
+	// StatusDisabled links were switched off by their owner.
+	StatusDisabled LinkStatus = "disabled"
+)
+
+func (s LinkStatus) Valid() bool {
+	switch s {
+	case StatusActive, StatusScheduled, StatusExpired, StatusDisabled:
+		return true
+	}
+	return false
+}
+
+// LinkSort orders a link listing.
+type LinkSort string
+
+const (
+	SortCreatedDesc LinkSort = "-created_at"
+	SortCreatedAsc  LinkSort = "created_at"
+	SortClicksDesc  LinkSort = "-clicks"
+	SortClicksAsc   LinkSort = "clicks"
+
+	DefaultLinkSort = SortCreatedDesc
+)
+
+func (s LinkSort) Valid() bool {
+	switch s {
+	case SortCreatedDesc, SortCreatedAsc, SortClicksDesc, SortClicksAsc:
+		return true
+	}
+	return false
+}
+
+// Descending reports whether the listing starts with the newest or most
+// clicked links.
+func (s LinkSort) Descending() bool {
+	return s == SortCreatedDesc || s == SortClicksDesc
+}
+
+// LinkCursor is the position of the last link of a page; the next page
+// starts right after it in the listing order.
+type LinkCursor struct {
+	Sort      LinkSort  `json:"s"`
+	CreatedAt time.Time `json:"c,omitzero"`
+	Clicks    int64     `json:"k,omitempty"`
+	ID        int64     `json:"i"`
+}
+
+// LinkFilter selects and orders links; zero fields do not filter.
+type LinkFilter struct {
+	Owner string
+	Tag   string
+	// FolderID selects the links in a folder and its subfolders.
+	FolderID int64
+	// Search matches a substring of the alias or the destination.
+	Search        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Status        LinkStatus
+	Sort          LinkSort
+	Limit         int
+	After         *LinkCursor
+}
+
+// LinkPage is one page of a listing. NextCursor is empty on the last page.
+type LinkPage struct {
+	Links      []CatalogEntry
+	NextCursor string
+}
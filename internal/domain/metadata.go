@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// Metadata describes a destination page for link previews.
+type Metadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	FaviconURL  string `json:"favicon_url,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	// FetchedAt and Error are only set for metadata stored with a link.
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+// OpenGraph overrides how a link unfurls in messengers and social networks.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+func (o OpenGraph) IsZero() bool {
+	return o == OpenGraph{}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "strings"
+
+const (
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// Rule sends matching visitors to Target instead of the link's default
+// destination. Empty conditions match any value; rules are evaluated in
+// Priority order and the first match wins.
+type Rule struct {
+	ID       int64
+	Priority int
+	OS       string
+	Device   string
+	Country  string
+	Target   string
+}
+
+// Visitor holds the request attributes rules are evaluated against.
+type Visitor struct {
+	OS      string
+	Device  string
+	Country string
+}
+
+func (r Rule) Matches(v Visitor) bool {
+	if r.OS != "" && r.OS != v.OS {
+		return false
+	}
+	if r.Device != "" && r.Device != v.Device {
+		return false
+	}
+	if r.Country != "" && r.Country != v.Country {
+		return false
+	}
+	return true
+}
+
+// Label is a stable description of the rule conditions used in analytics.
+func (r Rule) Label() string {
+	var parts []string
+	if r.OS != "" {
+		parts = append(parts, "os="+r.OS)
+	}
+	if r.Device != "" {
+		parts = append(parts, "device="+r.Device)
+	}
+	if r.Country != "" {
+		parts = append(parts, "country="+r.Country)
+	}
+	if len(parts) == 0 {
+		return "any"
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// ScheduleEntry switches the link's default destination to Target from
+// StartsAt on, until the next entry starts.
+type ScheduleEntry struct {
+	ID       int64
+	StartsAt time.Time
+	Target   string
+}
+
+// Active reports whether the link may be followed at t. A zero ActiveFrom
+// or ActiveUntil leaves that side of the window open.
+func (u *URL) Active(t time.Time) bool {
+	return !u.NotYetActive(t) && !u.Expired(t)
+}
+
+func (u *URL) NotYetActive(t time.Time) bool {
+	return !u.ActiveFrom.IsZero() && t.Before(u.ActiveFrom)
+}
+
+func (u *URL) Expired(t time.Time) bool {
+	return !u.ActiveUntil.IsZero() && !t.Before(u.ActiveUntil)
+}
+
+// ScheduledTarget returns the default destination in effect at t. The
+// schedule is expected in StartsAt order.
+func (u *URL) ScheduledTarget(t time.Time) string {
+	target := u.OriginalURL
+	for _, entry := range u.Schedule {
+		if entry.StartsAt.After(t) {
+			break
+		}
+		target = entry.Target
+	}
+	return target
+}
+
+// NextBoundary returns the first moment after t at which the link's
+// window or scheduled destination changes, or the zero time if it never
+// does.
+func (u *URL) NextBoundary(t time.Time) time.Time {
+	var next time.Time
+	consider := func(b time.Time) {
+		if b.After(t) && (next.IsZero() || b.Before(next)) {
+			next = b
+		}
+	}
+
+	if !u.ActiveFrom.IsZero() {
+		consider(u.ActiveFrom)
+	}
+	if !u.ActiveUntil.IsZero() {
+		consider(u.ActiveUntil)
+	}
+	for _, entry := range u.Schedule {
+		consider(entry.StartsAt)
+	}
+	return next
+}
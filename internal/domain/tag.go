@@ -0,0 +1,23 @@
+package domain
+
+type Tag struct {
+	ID   int64
+	Name string
+	// Links is the number of links carrying the tag.
+	Links int
+}
+
+type TagLinkStats struct {
+	Alias       string
+	OriginalURL string
+	Clicks      int
+}
+
+// TagReport sums up the clicks of all links carrying a tag.
+type TagReport struct {
+	Tag          string
+	TotalClicks  int
+	DailyStats   map[string]int
+	ChannelStats map[string]int
+	Links        []TagLinkStats
+}
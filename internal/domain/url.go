@@ -1,10 +1,228 @@
 package domain
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
+
+type RedirectType string
+
+const (
+	RedirectMovedPermanently RedirectType = "301"
+	RedirectFound            RedirectType = "302"
+	RedirectTemporary        RedirectType = "307"
+	RedirectPermanent        RedirectType = "308"
+	RedirectMetaRefresh      RedirectType = "meta"
+	DefaultRedirectType                   = RedirectTemporary
+)
+
+func (t RedirectType) Valid() bool {
+	switch t {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent, RedirectMetaRefresh:
+		return true
+	}
+	return false
+}
+
+func (t RedirectType) Permanent() bool {
+	return t == RedirectMovedPermanently || t == RedirectPermanent
+}
+
+// QueryMerge decides which value wins when a forwarded query parameter is
+// also present in the stored destination.
+type QueryMerge string
+
+const (
+	QueryMergeRequest QueryMerge = "request"
+	QueryMergeLink    QueryMerge = "link"
+	QueryMergeAppend  QueryMerge = "append"
+	DefaultQueryMerge            = QueryMergeRequest
+)
+
+func (m QueryMerge) Valid() bool {
+	switch m {
+	case QueryMergeRequest, QueryMergeLink, QueryMergeAppend:
+		return true
+	}
+	return false
+}
+
+// UTM holds the campaign tracking parameters merged into a destination.
+type UTM struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+func (u UTM) IsZero() bool {
+	return u == UTM{}
+}
+
+// Params returns the non-empty parameters keyed by their utm_* names.
+func (u UTM) Params() map[string]string {
+	params := make(map[string]string, 5)
+	for key, value := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	return params
+}
 
 type URL struct {
-	ID          int64
-	OriginalURL string
-	Alias       string
-	CreatedAt   time.Time
+	ID           int64
+	OriginalURL  string
+	Alias        string
+	RedirectType RedirectType
+	ForwardQuery bool
+	ForwardPath  bool
+	QueryMerge   QueryMerge
+	UTM          UTM
+	Rules        []Rule
+	// Locales maps lower-case language tags to alternative destinations.
+	Locales map[string]string
+	Split   Split
+	// ActiveFrom and ActiveUntil bound when the link redirects; zero
+	// values leave the window open.
+	ActiveFrom  time.Time
+	ActiveUntil time.Time
+	Schedule    []ScheduleEntry
+	// MaxClicks limits how often the link redirects, 0 for unlimited.
+	// ClicksUsed is only accurate when read from the database.
+	MaxClicks  int
+	ClicksUsed int
+	// FallbackURL receives visitors once the link can no longer redirect.
+	FallbackURL string
+	// PasswordHash is the bcrypt hash of the link password, empty when the
+	// link is not protected.
+	PasswordHash string
+	// RequireSignature rejects visits without a valid signed-link query.
+	RequireSignature bool
+	// Disabled links stop redirecting until re-enabled.
+	Disabled bool
+	// TargetDown is set by the target checker when the default destination
+	// keeps failing its probes.
+	TargetDown bool
+	// Preview links show an interstitial with the destination instead of
+	// redirecting straight away.
+	Preview bool
+	// OpenGraph is what social crawlers are shown instead of the redirect;
+	// zero means they are redirected like everyone else.
+	OpenGraph OpenGraph
+	// Tags are lower-case labels; they are only loaded where listed.
+	Tags []string
+	// Owner is a free-form label of who the link belongs to, set by the
+	// client at creation.
+	Owner string
+	// FolderID is the folder holding the link, 0 for the top level.
+	FolderID  int64
+	CreatedAt time.Time
+}
+
+type CreateURLInput struct {
+	OriginalURL      string
+	CustomAlias      string
+	RedirectType     RedirectType
+	ForwardQuery     bool
+	ForwardPath      bool
+	QueryMerge       QueryMerge
+	UTM              UTM
+	Rules            []Rule
+	Locales          map[string]string
+	Split            Split
+	ActiveFrom       time.Time
+	ActiveUntil      time.Time
+	Schedule         []ScheduleEntry
+	MaxClicks        int
+	FallbackURL      string
+	Password         string
+	RequireSignature bool
+	Preview          bool
+	OpenGraph        OpenGraph
+	Tags             []string
+	Owner            string
+	FolderID         int64
+}
+
+// UpdateURLInput holds the editable link fields; nil fields are left as is.
+type UpdateURLInput struct {
+	RedirectType *RedirectType
+	ForwardQuery *bool
+	ForwardPath  *bool
+	QueryMerge   *QueryMerge
+	// A pointer to the zero time removes the bound.
+	ActiveFrom  *time.Time
+	ActiveUntil *time.Time
+	MaxClicks   *int
+	FallbackURL *string
+	// Password replaces the link password; "" removes the protection.
+	Password         *string
+	RequireSignature *bool
+	Disabled         *bool
+	Preview          *bool
+	// OpenGraph replaces the custom preview card; a zero value removes it.
+	OpenGraph *OpenGraph
+	// FolderID moves the link; 0 moves it to the top level.
+	FolderID *int64
+}
+
+// RedirectRequest describes an incoming visit to a short link.
+type RedirectRequest struct {
+	Alias          string
+	PathSuffix     string
+	Query          url.Values
+	UserAgent      string
+	IP             string
+	AcceptLanguage string
+	// StickyVariant is the variant ID remembered for this visitor, 0 if none.
+	StickyVariant int64
+	// UnlockToken proves that the visitor entered the link password.
+	UnlockToken string
+	// Preview asks for the interstitial instead of a redirect.
+	Preview bool
+	// Head marks a HEAD request, which checks the link without visiting it.
+	Head bool
+}
+
+// ParamSkipPreview marks the visit from a preview's continue button so that
+// a link with Preview set redirects instead of showing it again. It is
+// never forwarded to the destination.
+const ParamSkipPreview = "skip_preview"
+
+// Redirect is the outcome of resolving a RedirectRequest.
+type Redirect struct {
+	Link   *URL
+	Target string
+	// Rule is the redirect rule that chose Target, nil for the default.
+	Rule *Rule
+	// Locale is the negotiated language tag, empty when none matched.
+	Locale string
+	// Variant is the split variant that chose Target, nil when not split.
+	Variant *Variant
+	// ValidUntil is when the resolution may change because of the link's
+	// window or schedule, zero if it never does.
+	ValidUntil time.Time
+	// Fallback is set when Target is the link's fallback URL because the
+	// link itself cannot redirect: disabled, expired, out of clicks or with
+	// its destination down.
+	Fallback bool
+	// Preview is set when the visitor should see the interstitial for
+	// Target instead of being redirected; Metadata then describes Target.
+	Preview  bool
+	Metadata *Metadata
+	// Unfurl is set for social crawlers, who get a page with these tags
+	// instead of a redirect.
+	Unfurl *OpenGraph
+	// Probe is set for HEAD requests and link preview bots: they are not
+	// counted as a click. Target is empty for a click-limited link, whose
+	// destination a probe must not reveal.
+	Probe bool
 }
@@ -0,0 +1,50 @@
+package domain
+
+// Rotation decides how a split link picks one of its variants.
+type Rotation string
+
+const (
+	RotationWeighted   Rotation = "weighted"
+	RotationRoundRobin Rotation = "round_robin"
+	DefaultRotation             = RotationWeighted
+)
+
+func (r Rotation) Valid() bool {
+	switch r {
+	case RotationWeighted, RotationRoundRobin:
+		return true
+	}
+	return false
+}
+
+// Variant is one destination of an A/B split. Weight is only used by
+// weighted rotation.
+type Variant struct {
+	ID     int64
+	Name   string
+	Target string
+	Weight int
+}
+
+// Split spreads visitors of a link over several destinations. A zero
+// Split (no variants) sends everybody to the link's default destination.
+type Split struct {
+	Rotation Rotation
+	// Sticky keeps returning visitors on the variant they saw first.
+	Sticky   bool
+	Variants []Variant
+}
+
+func (s Split) Enabled() bool {
+	return len(s.Variants) > 0
+}
+
+// Variant returns the variant with the given ID, or nil.
+func (s Split) Variant(id int64) *Variant {
+	for i := range s.Variants {
+		if s.Variants[i].ID == id {
+			return &s.Variants[i]
+		}
+	}
+	return nil
+}
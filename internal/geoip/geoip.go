@@ -0,0 +1,51 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Locator resolves IP addresses to ISO 3166-1 alpha-2 country codes using
+// an offline MaxMind database. A Locator without a database resolves every
+// address to an empty country, so geo rules simply never match.
+type Locator struct {
+	db *geoip2.Reader
+}
+
+func Open(path string) (*Locator, error) {
+	if path == "" {
+		return &Locator{}, nil
+	}
+
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database %s: %w", path, err)
+	}
+	return &Locator{db: db}, nil
+}
+
+func (l *Locator) Country(ip string) string {
+	if l.db == nil {
+		return ""
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	record, err := l.db.Country(parsed)
+	if err != nil {
+		return ""
+	}
+	return record.Country.IsoCode
+}
+
+func (l *Locator) Close() error {
+	if l.db == nil {
+		return nil
+	}
+	return l.db.Close()
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
 	"time"
 
 	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
 	"url-shortener-wb/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
@@ -41,7 +43,7 @@ func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request)
 			h.sendJSONError(w, "url not found", http.StatusNotFound)
 			return
 		}
-		h.logger.Error().Err(err).Str("alias", alias).Msg("get analytics failed")
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get analytics failed")
 		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -51,20 +53,112 @@ func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request)
 		DailyStats:     report.DailyStats,
 		MonthlyStats:   report.MonthlyStats,
 		UserAgentStats: report.UserAgentStats,
+		RuleStats:      report.RuleStats,
+		LocaleStats:    report.LocaleStats,
+		VariantStats:   report.VariantStats,
+		ChannelStats:   report.ChannelStats,
 		Clicks:         make([]dto.ClickAnalytics, len(report.Clicks)),
 	}
 
 	for i, click := range report.Clicks {
 		resp.Clicks[i] = dto.ClickAnalytics{
-			UserAgent: click.UserAgent,
-			IPAddress: click.IPAddress,
-			ClickedAt: click.ClickedAt.Format(time.RFC3339),
+			UserAgent:   click.UserAgent,
+			IPAddress:   click.IPAddress,
+			MatchedRule: click.MatchedRule,
+			Locale:      click.Locale,
+			Variant:     click.Variant,
+			Channel:     string(click.Channel),
+			ClickedAt:   click.ClickedAt.Format(time.RFC3339),
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		h.logger.Error().Err(err).Msg("failed to encode analytics response")
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode analytics response")
+	}
+}
+
+func (h *AnalyticsHandler) GetCampaignStats(w http.ResponseWriter, r *http.Request) {
+	campaign, err := url.PathUnescape(chi.URLParam(r, "campaign"))
+	if err != nil {
+		h.sendJSONError(w, "invalid campaign", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.usecase.GetCampaignStats(r.Context(), campaign)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidCampaign) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "campaign not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("campaign", campaign).Msg("get campaign stats failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.CampaignStatsResponse{
+		Campaign:    report.Campaign,
+		TotalClicks: report.TotalClicks,
+		DailyStats:  report.DailyStats,
+		SourceStats: report.SourceStats,
+		MediumStats: report.MediumStats,
+		Links:       make([]dto.CampaignLinkStats, len(report.Links)),
+	}
+
+	for i, link := range report.Links {
+		resp.Links[i] = dto.CampaignLinkStats{
+			Alias:       link.Alias,
+			OriginalURL: link.OriginalURL,
+			Source:      link.Source,
+			Medium:      link.Medium,
+			Clicks:      link.Clicks,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode campaign stats response")
+	}
+}
+
+// GetTagStats sums up the clicks of all links carrying the tag.
+func (h *AnalyticsHandler) GetTagStats(w http.ResponseWriter, r *http.Request) {
+	tag := chi.URLParam(r, "tag")
+
+	report, err := h.usecase.GetTagStats(r.Context(), tag)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidTag) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "tag not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("tag", tag).Msg("get tag stats failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.TagStatsResponse{
+		Tag:          report.Tag,
+		TotalClicks:  report.TotalClicks,
+		DailyStats:   report.DailyStats,
+		ChannelStats: report.ChannelStats,
+		Links:        make([]dto.TagLinkStats, len(report.Links)),
+	}
+
+	for i, link := range report.Links {
+		resp.Links[i] = dto.TagLinkStats(link)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode tag stats response")
 	}
 }
 
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/usecase"
+)
+
+// maxBatchBody bounds the request body of a batch create.
+const maxBatchBody = 32 << 20
+
+// CreateShortURLs creates a batch of links. The response lists every item
+// in request order: 201 when all were created, 207 when some failed and
+// 422 when an atomic batch was rejected.
+func (h *URLHandler) CreateShortURLs(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBody)
+
+	var req dto.BatchCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.sendJSONError(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	inputs := make([]domain.CreateURLInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = createInputFromDTO(item)
+	}
+
+	results, err := h.usecase.CreateShortURLs(r.Context(), inputs, req.Atomic)
+	if err != nil && !errors.Is(err, usecase.ErrBatchRejected) {
+		if errors.Is(err, usecase.ErrInvalidBatch) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrAliasExists) {
+			h.sendJSONError(w, "alias already exists", http.StatusConflict)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Int("items", len(inputs)).Msg("batch create failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.BatchCreateResponse{Results: make([]dto.BatchItemResult, len(results))}
+	for i, res := range results {
+		item := dto.BatchItemResult{Index: i}
+		switch {
+		case res.Err == nil && res.Alias != "":
+			item.Alias = res.Alias
+			item.ShortURL = shortURL(r, res.Alias)
+			resp.Created++
+		case res.Err == nil:
+			// Valid, but not written because the atomic batch was rejected.
+		default:
+			item.Error = batchItemError(res.Err)
+			resp.Failed++
+		}
+		resp.Results[i] = item
+	}
+
+	status := http.StatusCreated
+	switch {
+	case err != nil:
+		status = http.StatusUnprocessableEntity
+	case resp.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"url-shortener-wb/internal/catalog"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/usecase"
+)
+
+// maxImportBody bounds the CSV uploaded to ImportLinks.
+const maxImportBody = 64 << 20
+
+// ImportLinks creates links from a CSV catalog sent as the request body.
+// With ?dry_run=true nothing is written and the response only reports the
+// lines that would fail.
+func (h *URLHandler) ImportLinks(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			h.sendJSONError(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBody)
+	report, err := catalog.Import(r.Context(), h.usecase, r.Body, dryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			h.sendJSONError(w, "request body too large", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, catalog.ErrInvalidCSV), errors.Is(err, usecase.ErrInvalidBatch):
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		default:
+			logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("catalog import failed")
+			h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.ImportResponse{
+		DryRun:  report.DryRun,
+		Created: report.Succeeded,
+		Failed:  report.Failed,
+		Rows:    make([]dto.ImportRowResult, len(report.Rows)),
+	}
+	for i, row := range report.Rows {
+		item := dto.ImportRowResult{Line: row.Line, Alias: row.Alias}
+		if row.Err != nil {
+			item.Error = batchItemError(row.Err)
+		} else if row.Alias != "" && !report.DryRun {
+			item.ShortURL = shortURL(r, row.Alias)
+		}
+		resp.Rows[i] = item
+	}
+
+	status := http.StatusCreated
+	switch {
+	case report.DryRun:
+		status = http.StatusOK
+	case report.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+// ExportLinks streams the whole catalog as CSV with click totals.
+func (h *URLHandler) ExportLinks(w http.ResponseWriter, r *http.Request) {
+	out := &csvResponse{w: w}
+	if err := catalog.Export(r.Context(), h.usecase, out); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("catalog export failed")
+		// The status line is gone once rows were written; the truncated
+		// file is all the client gets then.
+		if !out.wrote {
+			h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// csvResponse defers the CSV headers and the 200 status to the first write,
+// which the CSV writer only makes once its buffer fills or the export
+// ends. An export failing on its first page can still answer with an error.
+type csvResponse struct {
+	w     http.ResponseWriter
+	wrote bool
+}
+
+func (c *csvResponse) Write(p []byte) (int, error) {
+	if !c.wrote {
+		c.wrote = true
+		c.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		c.w.Header().Set("Content-Disposition", `attachment; filename="links.csv"`)
+	}
+	return c.w.Write(p)
+}
+
+// batchItemError is the message shown for one failed item of a batch or
+// import; unexpected errors are not exposed.
+func batchItemError(err error) string {
+	if isValidationError(err) || errors.Is(err, usecase.ErrAliasExists) || errors.Is(err, catalog.ErrInvalidRow) {
+		return err.Error()
+	}
+	return "internal server error"
+}
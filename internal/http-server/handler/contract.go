@@ -2,15 +2,47 @@ package handler
 
 import (
 	"context"
+	"net/url"
+	"time"
+
 	"url-shortener-wb/internal/domain"
 )
 
 type URLUsecase interface {
-	CreateShortURL(ctx context.Context, originalURL, customAlias string) (string, error)
-	GetOriginalURL(ctx context.Context, alias string) (string, error)
+	CreateShortURL(ctx context.Context, in domain.CreateURLInput) (string, error)
+	CreateShortURLs(ctx context.Context, inputs []domain.CreateURLInput, atomic bool) ([]domain.BatchResult, error)
+	ImportURLs(ctx context.Context, inputs []domain.CreateURLInput, dryRun bool) ([]domain.BatchResult, error)
+	ExportURLs(ctx context.Context, fn func(domain.CatalogEntry) error) error
+	GetURL(ctx context.Context, alias string) (*domain.URL, *domain.Metadata, error)
+	ListURLs(ctx context.Context, filter domain.LinkFilter, cursor string) (*domain.LinkPage, error)
+	UpdateURL(ctx context.Context, alias string, in domain.UpdateURLInput) (*domain.URL, error)
+	GetOriginalURL(ctx context.Context, req domain.RedirectRequest) (*domain.Redirect, error)
+	UnlockURL(ctx context.Context, alias, password, ip string) (string, time.Time, error)
+	SignLink(ctx context.Context, alias string, ttl time.Duration) (url.Values, time.Time, error)
+	GetRules(ctx context.Context, alias string) ([]domain.Rule, error)
+	ReplaceRules(ctx context.Context, alias string, rules []domain.Rule) ([]domain.Rule, error)
+	GetLocales(ctx context.Context, alias string) (map[string]string, error)
+	ReplaceLocales(ctx context.Context, alias string, locales map[string]string) (map[string]string, error)
+	GetSplit(ctx context.Context, alias string) (*domain.Split, error)
+	ReplaceSplit(ctx context.Context, alias string, split domain.Split) (*domain.Split, error)
+	GetSchedule(ctx context.Context, alias string) ([]domain.ScheduleEntry, error)
+	ReplaceSchedule(ctx context.Context, alias string, schedule []domain.ScheduleEntry) ([]domain.ScheduleEntry, error)
+	ReplaceLinkTags(ctx context.Context, alias string, tags []string) ([]string, error)
+	AssignTags(ctx context.Context, aliases, add, remove []string) ([]string, error)
+	ListTags(ctx context.Context) ([]domain.Tag, error)
+	CreateTag(ctx context.Context, name string) (*domain.Tag, error)
+	RenameTag(ctx context.Context, name, newName string) error
+	DeleteTag(ctx context.Context, name string) error
+	ListFolders(ctx context.Context) ([]*domain.Folder, error)
+	GetFolder(ctx context.Context, id int64) (*domain.Folder, error)
+	CreateFolder(ctx context.Context, parentID int64, name string) (*domain.Folder, error)
+	UpdateFolder(ctx context.Context, id int64, in domain.UpdateFolderInput) (*domain.Folder, error)
+	DeleteFolder(ctx context.Context, id int64) error
 }
 
 type AnalyticsUsecase interface {
 	GetAnalytics(ctx context.Context, alias string) (*domain.AnalyticsReport, error)
-	RecordClick(ctx context.Context, alias, userAgent, ip string) error
+	GetCampaignStats(ctx context.Context, campaign string) (*domain.CampaignReport, error)
+	GetTagStats(ctx context.Context, tag string) (*domain.TagReport, error)
+	TrackClick(ctx context.Context, alias string, click domain.Click)
 }
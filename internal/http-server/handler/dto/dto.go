@@ -1,8 +1,173 @@
 package dto
 
+import "time"
+
 type CreateShortURLRequest struct {
-	URL    string `json:"url" validate:"required,url"`
-	Custom string `json:"custom,omitempty" validate:"omitempty,min=3,max=20,alphanum"`
+	URL          string            `json:"url" validate:"required,url"`
+	Custom       string            `json:"custom,omitempty" validate:"omitempty,min=3,max=20,alphanum"`
+	RedirectType string            `json:"redirect_type,omitempty" validate:"omitempty,oneof=301 302 307 308 meta"`
+	ForwardQuery bool              `json:"forward_query,omitempty"`
+	ForwardPath  bool              `json:"forward_path,omitempty"`
+	QueryMerge   string            `json:"query_merge,omitempty" validate:"omitempty,oneof=request link append"`
+	UTM          *UTM              `json:"utm,omitempty"`
+	Rules        []Rule            `json:"rules,omitempty"`
+	Locales      map[string]string `json:"locales,omitempty"`
+	Split        *Split            `json:"split,omitempty"`
+	ActiveFrom   *time.Time        `json:"active_from,omitempty"`
+	ActiveUntil  *time.Time        `json:"active_until,omitempty"`
+	Schedule     []ScheduleEntry   `json:"schedule,omitempty"`
+	MaxClicks    int               `json:"max_clicks,omitempty" validate:"omitempty,min=0"`
+	FallbackURL  string            `json:"fallback_url,omitempty"`
+	Password     string            `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+	RequireSig   bool              `json:"require_signature,omitempty"`
+	Preview      bool              `json:"preview,omitempty"`
+	OpenGraph    *OpenGraph        `json:"open_graph,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Owner        string            `json:"owner,omitempty"`
+	FolderID     int64             `json:"folder_id,omitempty"`
+}
+
+// OpenGraph is the card shown when the link is shared in messengers and
+// social networks.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+type Rule struct {
+	Priority int    `json:"priority"`
+	OS       string `json:"os,omitempty"`
+	Device   string `json:"device,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Target   string `json:"target"`
+}
+
+type ReplaceRulesRequest struct {
+	Rules []Rule `json:"rules"`
+}
+
+type RulesResponse struct {
+	Rules []Rule `json:"rules"`
+}
+
+type ReplaceLocalesRequest struct {
+	Locales map[string]string `json:"locales"`
+}
+
+type LocalesResponse struct {
+	Locales map[string]string `json:"locales"`
+}
+
+type Split struct {
+	Rotation string    `json:"rotation,omitempty"`
+	Sticky   bool      `json:"sticky"`
+	Variants []Variant `json:"variants"`
+}
+
+type Variant struct {
+	ID     int64  `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+type ScheduleEntry struct {
+	ID       int64     `json:"id,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+	Target   string    `json:"target"`
+}
+
+type ReplaceScheduleRequest struct {
+	Schedule []ScheduleEntry `json:"schedule"`
+}
+
+type ScheduleResponse struct {
+	Schedule []ScheduleEntry `json:"schedule"`
+}
+
+type UTM struct {
+	Source   string `json:"source,omitempty" validate:"omitempty,max=255"`
+	Medium   string `json:"medium,omitempty" validate:"omitempty,max=255"`
+	Campaign string `json:"campaign,omitempty" validate:"omitempty,max=255"`
+	Term     string `json:"term,omitempty" validate:"omitempty,max=255"`
+	Content  string `json:"content,omitempty" validate:"omitempty,max=255"`
+}
+
+type UpdateLinkRequest struct {
+	RedirectType *string `json:"redirect_type,omitempty"`
+	ForwardQuery *bool   `json:"forward_query,omitempty"`
+	ForwardPath  *bool   `json:"forward_path,omitempty"`
+	QueryMerge   *string `json:"query_merge,omitempty"`
+	// ActiveFrom and ActiveUntil take RFC 3339 times; "" removes the bound.
+	ActiveFrom  *string `json:"active_from,omitempty"`
+	ActiveUntil *string `json:"active_until,omitempty"`
+	MaxClicks   *int    `json:"max_clicks,omitempty"`
+	FallbackURL *string `json:"fallback_url,omitempty"`
+	// Password replaces the link password; "" removes it.
+	Password   *string `json:"password,omitempty"`
+	RequireSig *bool   `json:"require_signature,omitempty"`
+	Disabled   *bool   `json:"disabled,omitempty"`
+	Preview    *bool   `json:"preview,omitempty"`
+	// OpenGraph replaces the custom card; {} removes it.
+	OpenGraph *OpenGraph `json:"open_graph,omitempty"`
+	// FolderID moves the link; 0 moves it to the top level.
+	FolderID *int64 `json:"folder_id,omitempty"`
+}
+
+type LinkResponse struct {
+	ShortURL     string     `json:"short_url"`
+	Alias        string     `json:"alias"`
+	OriginalURL  string     `json:"original_url"`
+	RedirectType string     `json:"redirect_type"`
+	ForwardQuery bool       `json:"forward_query"`
+	ForwardPath  bool       `json:"forward_path"`
+	QueryMerge   string     `json:"query_merge"`
+	UTM          *UTM       `json:"utm,omitempty"`
+	ActiveFrom   string     `json:"active_from,omitempty"`
+	ActiveUntil  string     `json:"active_until,omitempty"`
+	MaxClicks    int        `json:"max_clicks,omitempty"`
+	ClicksUsed   int        `json:"clicks_used,omitempty"`
+	FallbackURL  string     `json:"fallback_url,omitempty"`
+	Protected    bool       `json:"password_protected"`
+	RequireSig   bool       `json:"require_signature"`
+	Disabled     bool       `json:"disabled"`
+	TargetDown   bool       `json:"target_down,omitempty"`
+	Preview      bool       `json:"preview"`
+	OpenGraph    *OpenGraph `json:"open_graph,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Owner        string     `json:"owner,omitempty"`
+	FolderID     int64      `json:"folder_id,omitempty"`
+	// TotalClicks is only filled in listings.
+	TotalClicks *int64    `json:"total_clicks,omitempty"`
+	CreatedAt   string    `json:"created_at"`
+	Metadata    *Metadata `json:"metadata,omitempty"`
+}
+
+type LinkListResponse struct {
+	Links      []LinkResponse `json:"links"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Metadata describes the destination page as fetched when the link was
+// created.
+type Metadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	FaviconURL  string `json:"favicon_url,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	FetchedAt   string `json:"fetched_at"`
+	Error       string `json:"error,omitempty"`
+}
+
+type SignLinkRequest struct {
+	// TTL is a Go duration such as "24h".
+	TTL string `json:"ttl"`
+}
+
+type SignLinkResponse struct {
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
 }
 
 type CreateShortURLResponse struct {
@@ -10,16 +175,173 @@ type CreateShortURLResponse struct {
 	Alias    string `json:"alias"`
 }
 
+// BatchCreateRequest creates many links at once. Unless Atomic is set, valid
+// items are created even when others fail.
+type BatchCreateRequest struct {
+	Atomic bool                    `json:"atomic,omitempty"`
+	Items  []CreateShortURLRequest `json:"items"`
+}
+
+type BatchCreateResponse struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []BatchItemResult `json:"results"`
+}
+
+// ImportResponse reports a catalog import; in a dry run Created counts the
+// lines that would be created.
+type ImportResponse struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult reports one CSV line, Line counting the header as 1.
+type ImportRowResult struct {
+	Line     int    `json:"line"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchItemResult reports one item of a batch, Index being its position in
+// the request.
+type BatchItemResult struct {
+	Index    int    `json:"index"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
 type AnalyticsResponse struct {
 	TotalClicks    int              `json:"total_clicks"`
 	DailyStats     map[string]int   `json:"daily_stats"`
 	MonthlyStats   map[string]int   `json:"monthly_stats"`
 	UserAgentStats map[string]int   `json:"user_agent_stats"`
+	RuleStats      map[string]int   `json:"rule_stats"`
+	LocaleStats    map[string]int   `json:"locale_stats"`
+	VariantStats   map[string]int   `json:"variant_stats"`
+	ChannelStats   map[string]int   `json:"channel_stats"`
 	Clicks         []ClickAnalytics `json:"clicks"`
 }
 
 type ClickAnalytics struct {
-	UserAgent string `json:"user_agent"`
-	IPAddress string `json:"ip_address"`
-	ClickedAt string `json:"clicked_at"`
+	UserAgent   string `json:"user_agent"`
+	IPAddress   string `json:"ip_address"`
+	MatchedRule string `json:"matched_rule,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	Variant     string `json:"variant,omitempty"`
+	Channel     string `json:"channel"`
+	ClickedAt   string `json:"clicked_at"`
+}
+
+type HealthResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks,omitempty"`
+}
+
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type CampaignStatsResponse struct {
+	Campaign    string              `json:"campaign"`
+	TotalClicks int                 `json:"total_clicks"`
+	DailyStats  map[string]int      `json:"daily_stats"`
+	SourceStats map[string]int      `json:"source_stats"`
+	MediumStats map[string]int      `json:"medium_stats"`
+	Links       []CampaignLinkStats `json:"links"`
+}
+
+type CampaignLinkStats struct {
+	Alias       string `json:"alias"`
+	OriginalURL string `json:"original_url"`
+	Source      string `json:"source"`
+	Medium      string `json:"medium"`
+	Clicks      int    `json:"clicks"`
+}
+
+type TagStatsResponse struct {
+	Tag          string         `json:"tag"`
+	TotalClicks  int            `json:"total_clicks"`
+	DailyStats   map[string]int `json:"daily_stats"`
+	ChannelStats map[string]int `json:"channel_stats"`
+	Links        []TagLinkStats `json:"links"`
+}
+
+type TagLinkStats struct {
+	Alias       string `json:"alias"`
+	OriginalURL string `json:"original_url"`
+	Clicks      int    `json:"clicks"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Links int    `json:"links"`
+}
+
+type TagListResponse struct {
+	Tags []Tag `json:"tags"`
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+type ReplaceTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type TagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// AssignTagsRequest adds and removes tags on many links at once.
+type AssignTagsRequest struct {
+	Aliases []string `json:"aliases"`
+	Add     []string `json:"add,omitempty"`
+	Remove  []string `json:"remove,omitempty"`
+}
+
+type AssignTagsResponse struct {
+	Updated  int      `json:"updated"`
+	NotFound []string `json:"not_found,omitempty"`
+}
+
+type Folder struct {
+	ID       int64  `json:"id"`
+	ParentID int64  `json:"parent_id,omitempty"`
+	Name     string `json:"name"`
+	// Path joins the names from the top level down, e.g. "Marketing/2026".
+	Path      string `json:"path"`
+	Links     int    `json:"links"`
+	CreatedAt string `json:"created_at"`
+}
+
+type FolderListResponse struct {
+	Folders []Folder `json:"folders"`
+}
+
+type CreateFolderRequest struct {
+	Name     string `json:"name"`
+	ParentID int64  `json:"parent_id,omitempty"`
+}
+
+// UpdateFolderRequest renames or moves a folder; a parent_id of 0 moves it
+// to the top level.
+type UpdateFolderRequest struct {
+	Name     *string `json:"name,omitempty"`
+	ParentID *int64  `json:"parent_id,omitempty"`
+}
+
+type PreviewResponse struct {
+	Alias       string `json:"alias"`
+	Target      string `json:"target"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	FaviconURL  string `json:"favicon_url,omitempty"`
+	ContinueURL string `json:"continue_url"`
 }
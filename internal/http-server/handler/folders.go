@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func folderToDTO(f *domain.Folder) dto.Folder {
+	return dto.Folder{
+		ID:        f.ID,
+		ParentID:  f.ParentID,
+		Name:      f.Name,
+		Path:      f.Path,
+		Links:     f.Links,
+		CreatedAt: f.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (h *URLHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	folders, err := h.usecase.ListFolders(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("list folders failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.FolderListResponse{Folders: make([]dto.Folder, len(folders))}
+	for i, folder := range folders {
+		resp.Folders[i] = folderToDTO(folder)
+	}
+	h.sendFolderJSON(w, r, http.StatusOK, resp)
+}
+
+func (h *URLHandler) GetFolder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.folderID(w, r)
+	if !ok {
+		return
+	}
+
+	folder, err := h.usecase.GetFolder(r.Context(), id)
+	if err != nil {
+		h.sendFolderError(w, r, err, "get folder failed")
+		return
+	}
+	h.sendFolderJSON(w, r, http.StatusOK, folderToDTO(folder))
+}
+
+func (h *URLHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	folder, err := h.usecase.CreateFolder(r.Context(), req.ParentID, req.Name)
+	if err != nil {
+		h.sendFolderError(w, r, err, "create folder failed")
+		return
+	}
+	h.sendFolderJSON(w, r, http.StatusCreated, folderToDTO(folder))
+}
+
+// UpdateFolder renames a folder or moves it with everything it holds.
+func (h *URLHandler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.folderID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	folder, err := h.usecase.UpdateFolder(r.Context(), id, domain.UpdateFolderInput{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		h.sendFolderError(w, r, err, "update folder failed")
+		return
+	}
+	h.sendFolderJSON(w, r, http.StatusOK, folderToDTO(folder))
+}
+
+// DeleteFolder removes a folder once it holds no links or subfolders.
+func (h *URLHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.folderID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteFolder(r.Context(), id); err != nil {
+		h.sendFolderError(w, r, err, "delete folder failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *URLHandler) folderID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.sendJSONError(w, "folder not found", http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *URLHandler) sendFolderError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case isValidationError(err):
+		h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrNotFound):
+		h.sendJSONError(w, "folder not found", http.StatusNotFound)
+	case errors.Is(err, usecase.ErrFolderExists), errors.Is(err, usecase.ErrFolderNotEmpty):
+		h.sendJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("folder_id", chi.URLParam(r, "id")).Msg(msg)
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *URLHandler) sendFolderJSON(w http.ResponseWriter, r *http.Request, status int, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	statusOK       = "ok"
+	statusFailing  = "failing"
+	statusShutdown = "shutting_down"
+)
+
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	checks       []DependencyCheck
+	timeout      time.Duration
+	logger       *zlog.Zerolog
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(checks []DependencyCheck, timeout time.Duration, logger *zlog.Zerolog) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// SetShuttingDown makes readiness fail so that load balancers stop routing
+// new traffic while in-flight requests are drained.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, dto.HealthResponse{Status: statusOK}, http.StatusOK)
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		h.sendJSON(w, dto.HealthResponse{Status: statusShutdown}, http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	resp := dto.HealthResponse{
+		Status: statusOK,
+		Checks: make(map[string]dto.DependencyStatus, len(h.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check DependencyCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			status := dto.DependencyStatus{
+				Status:    statusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			// The endpoint is unauthenticated, so the cause, which may
+			// name hosts and users, only goes to the log.
+			if err != nil {
+				status.Status = statusFailing
+				logging.FromContext(ctx, h.logger).Warn().Err(err).Str("dependency", check.Name).Msg("readiness check failed")
+			}
+
+			mu.Lock()
+			resp.Checks[check.Name] = status
+			if err != nil {
+				resp.Status = statusFailing
+			}
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if resp.Status != statusOK {
+		code = http.StatusServiceUnavailable
+	}
+	h.sendJSON(w, resp, code)
+}
+
+func (h *HealthHandler) sendJSON(w http.ResponseWriter, resp dto.HealthResponse, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
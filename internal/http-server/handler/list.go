@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+)
+
+// ListLinks returns a page of links. Filters: owner, tag, folder (with its
+// subfolders), q (substring of alias or destination), created_after,
+// created_before, status; order: sort; paging: limit and cursor, taken
+// from next_cursor of the previous page.
+func (h *URLHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	filter, err := linkFilter(r.URL.Query())
+	if err != nil {
+		h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.usecase.ListURLs(r.Context(), filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("list links failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.LinkListResponse{
+		Links:      make([]dto.LinkResponse, len(page.Links)),
+		NextCursor: page.NextCursor,
+	}
+	for i, entry := range page.Links {
+		link := linkResponse(r, entry.URL)
+		link.TotalClicks = &entry.Clicks
+		resp.Links[i] = link
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func linkFilter(q url.Values) (domain.LinkFilter, error) {
+	filter := domain.LinkFilter{
+		Owner:  q.Get("owner"),
+		Tag:    q.Get("tag"),
+		Search: q.Get("q"),
+		Status: domain.LinkStatus(q.Get("status")),
+		Sort:   domain.LinkSort(q.Get("sort")),
+	}
+
+	if v := q.Get("folder"); v != "" {
+		folderID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, errors.New("folder must be a folder id")
+		}
+		filter.FolderID = folderID
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("limit must be a number")
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.CreatedAfter, err = timeParam(q, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = timeParam(q, "created_before"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// timeParam parses an RFC 3339 time or a date, taken as midnight UTC.
+func timeParam(q url.Values, name string) (time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time or a YYYY-MM-DD date", name)
+}
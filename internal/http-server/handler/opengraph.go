@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/pages"
+)
+
+// renderUnfurl answers a social crawler with the link's preview card. It
+// is always HTML: crawlers read the meta tags whatever they send in Accept.
+func (h *URLHandler) renderUnfurl(w http.ResponseWriter, r *http.Request, alias string, og *domain.OpenGraph) {
+	metrics.Redirects.WithLabelValues("unfurl").Inc()
+
+	title := og.Title
+	if title == "" {
+		title = alias
+	}
+	h.renderPage(w, r, pages.OpenGraph, http.StatusOK, pages.Data{
+		Title:     title,
+		OpenGraph: og,
+		URL:       shortURL(r, alias),
+	})
+}
+
+func openGraphFromDTO(og *dto.OpenGraph) domain.OpenGraph {
+	if og == nil {
+		return domain.OpenGraph{}
+	}
+	return domain.OpenGraph{
+		Title:       og.Title,
+		Description: og.Description,
+		ImageURL:    og.Image,
+	}
+}
+
+func openGraphToDTO(og domain.OpenGraph) *dto.OpenGraph {
+	if og.IsZero() {
+		return nil
+	}
+	return &dto.OpenGraph{
+		Title:       og.Title,
+		Description: og.Description,
+		Image:       og.ImageURL,
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener-wb/internal/pages"
+	"url-shortener-wb/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	unlockCookiePrefix = "sp_"
+	maxPasswordForm    = 4 << 10
+)
+
+func (h *URLHandler) renderPasswordForm(w http.ResponseWriter, r *http.Request, status int, message string) {
+	h.renderPage(w, r, pages.Password, status, pages.Data{
+		Title:   "Password required",
+		Message: "This link is password protected.",
+		Action:  r.URL.RequestURI(),
+		Error:   message,
+	})
+}
+
+// UnlockLink accepts the password form. On success the visitor gets an
+// unlock cookie and is sent back to the link with the original path and
+// query, so that a form posted from a preview (/s/{alias}+) returns to it.
+func (h *URLHandler) UnlockLink(w http.ResponseWriter, r *http.Request) {
+	alias, _ := strings.CutSuffix(chi.URLParam(r, "alias"), "+")
+	if alias == "" {
+		h.sendJSONError(w, "invalid url", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasswordForm)
+	if err := r.ParseForm(); err != nil {
+		h.sendPasswordError(w, r, http.StatusBadRequest, "invalid form", "Invalid form submission.")
+		return
+	}
+
+	token, expires, err := h.usecase.UnlockURL(r.Context(), alias, r.PostFormValue("password"), clientIP(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrWrongPassword):
+			h.sendPasswordError(w, r, http.StatusForbidden, "wrong password", "Wrong password, please try again.")
+		case errors.Is(err, usecase.ErrTooManyAttempts):
+			h.sendPasswordError(w, r, http.StatusTooManyRequests, "too many attempts", "Too many attempts, please try again later.")
+		default:
+			h.sendRedirectError(w, r, alias, err)
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     unlockCookiePrefix + alias,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   int(time.Until(expires).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+}
+
+func (h *URLHandler) sendPasswordError(w http.ResponseWriter, r *http.Request, status int, message, formMessage string) {
+	if !wantsHTML(w, r) {
+		h.sendJSONError(w, message, status)
+		return
+	}
+	h.renderPasswordForm(w, r, status, formMessage)
+}
+
+func unlockToken(r *http.Request, alias string) string {
+	cookie, err := r.Cookie(unlockCookiePrefix + alias)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// clientIP is the visitor's address without the port. Behind a trusted
+// proxy RealIPMiddleware has already put the forwarded address in
+// RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/pages"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// renderPreview shows where a link goes instead of redirecting. The
+// continue button leads back through the short link, so the visit is
+// counted there like any other click.
+func (h *URLHandler) renderPreview(w http.ResponseWriter, r *http.Request, alias string, channel domain.Channel, redirect *domain.Redirect) {
+	metrics.Redirects.WithLabelValues("preview").Inc()
+	continueURL := previewContinueURL(r, alias, channel)
+
+	if !wantsHTML(w, r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		resp := dto.PreviewResponse{
+			Alias:       alias,
+			Target:      redirect.Target,
+			ContinueURL: continueURL,
+		}
+		if meta := redirect.Metadata; meta != nil {
+			resp.Title = meta.Title
+			resp.Description = meta.Description
+			resp.ImageURL = meta.ImageURL
+			resp.FaviconURL = meta.FaviconURL
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+		}
+		return
+	}
+
+	h.renderPage(w, r, pages.Interstitial, http.StatusOK, pages.Data{
+		Title:       "Check where this link goes",
+		Message:     "This short link leads to:",
+		Target:      redirect.Target,
+		ContinueURL: continueURL,
+		Destination: redirect.Metadata,
+	})
+}
+
+// previewContinueURL is the short link with the visit's entry path, path
+// and query, marked so that it redirects even when the link always shows a
+// preview.
+func previewContinueURL(r *http.Request, alias string, channel domain.Channel) string {
+	link := absoluteURL(r, entryPath(channel)+alias)
+	if suffix := chi.URLParam(r, "*"); suffix != "" {
+		link += "/" + strings.TrimPrefix(suffix, "/")
+	}
+	query := r.URL.Query()
+	query.Set(domain.ParamSkipPreview, "1")
+	return link + "?" + query.Encode()
+}
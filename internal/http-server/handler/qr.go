@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/qr"
+	"url-shortener-wb/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetQR renders a QR code for the link. The code points at the /q/ entry so
+// that scans are told apart from clicks.
+func (h *URLHandler) GetQR(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	opts, err := qrOptions(r)
+	if err != nil {
+		h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, _, err := h.usecase.GetURL(r.Context(), alias); err != nil {
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get link for qr failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	image, contentType, err := h.qr.Encode(absoluteURL(r, entryPath(domain.ChannelQR)+alias), opts)
+	if err != nil {
+		if errors.Is(err, qr.ErrInvalidOptions) || errors.Is(err, qr.ErrNoLogo) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("render qr failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if _, err := w.Write(image); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to write qr code")
+	}
+}
+
+func qrOptions(r *http.Request) (qr.Options, error) {
+	q := r.URL.Query()
+	opts := qr.DefaultOptions()
+
+	if v := q.Get("format"); v != "" {
+		opts.Format = qr.Format(strings.ToLower(v))
+	}
+	if v := q.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, errors.New("size must be a number of pixels")
+		}
+		opts.Size = size
+	}
+	if v := q.Get("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, errors.New("margin must be a number of modules")
+		}
+		opts.Margin = margin
+	}
+	if v := q.Get("ecc"); v != "" {
+		opts.ECC = strings.ToUpper(v)
+	}
+	if v := q.Get("fg"); v != "" {
+		c, err := qr.ParseColor(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Foreground = c
+	}
+	if v := q.Get("bg"); v != "" {
+		c, err := qr.ParseColor(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Background = c
+	}
+	if v := q.Get("logo"); v != "" {
+		logo, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, errors.New("logo must be true or false")
+		}
+		opts.Logo = logo
+	}
+	return opts, opts.Validate()
+}
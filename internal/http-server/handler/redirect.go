@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/metrics"
+)
+
+const permanentRedirectMaxAge = 24 * time.Hour
+
+var metaRefreshTemplate = template.Must(template.New("meta").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0; url={{.}}">
+<meta name="robots" content="noindex">
+<title>Redirecting…</title>
+</head>
+<body>
+<p>Redirecting to <a href="{{.}}">{{.}}</a>…</p>
+</body>
+</html>
+`))
+
+// redirect sends the visitor to the resolved target using the link's
+// redirect type. Permanent redirects may be cached by browsers and proxies;
+// temporary ones, and any redirect of a split, click-limited, password
+// protected or signed link, are marked no-store so that every click reaches
+// the service and no shared cache hands out a redirect the visitor has not
+// unlocked.
+func (h *URLHandler) redirect(w http.ResponseWriter, r *http.Request, redirect *domain.Redirect) {
+	if redirect.Fallback {
+		// The link's own redirect type may be permanent; a fallback never is.
+		w.Header().Set("Cache-Control", "no-store")
+		metrics.Redirects.WithLabelValues("fallback").Inc()
+		http.Redirect(w, r, redirect.Target, http.StatusTemporaryRedirect)
+		return
+	}
+	if redirect.Probe && redirect.Target == "" {
+		// The link is live, but a probe does not get to see where it goes.
+		w.Header().Set("Cache-Control", "no-store")
+		metrics.Redirects.WithLabelValues("probe").Inc()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	target, redirectType := redirect.Target, redirect.Link.RedirectType
+	link := redirect.Link
+	cacheable := !link.Split.Enabled() && link.MaxClicks == 0 &&
+		link.PasswordHash == "" && !link.RequireSignature
+	if redirectType.Permanent() && cacheable {
+		// Never let a cached redirect outlive the next schedule switch.
+		maxAge := permanentRedirectMaxAge
+		if !redirect.ValidUntil.IsZero() {
+			maxAge = min(maxAge, time.Until(redirect.ValidUntil))
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	metrics.Redirects.WithLabelValues(string(redirectType)).Inc()
+
+	switch redirectType {
+	case domain.RedirectMetaRefresh:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := metaRefreshTemplate.Execute(w, target); err != nil {
+			h.logger.Error().Err(err).Msg("failed to render redirect page")
+		}
+	default:
+		code, err := strconv.Atoi(string(redirectType))
+		if err != nil {
+			code = http.StatusTemporaryRedirect
+		}
+		http.Redirect(w, r, target, code)
+	}
+}
+
+func shortURL(r *http.Request, alias string) string {
+	return absoluteURL(r, entryPath(domain.ChannelLink)+alias)
+}
+
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, path)
+}
+
+// entryPath is the path prefix visits from a channel arrive on.
+func entryPath(channel domain.Channel) string {
+	if channel == domain.ChannelQR {
+		return "/q/"
+	}
+	return "/s/"
+}
+
+func linkResponse(r *http.Request, link *domain.URL) dto.LinkResponse {
+	return dto.LinkResponse{
+		ShortURL:     shortURL(r, link.Alias),
+		Alias:        link.Alias,
+		OriginalURL:  link.OriginalURL,
+		RedirectType: string(link.RedirectType),
+		ForwardQuery: link.ForwardQuery,
+		ForwardPath:  link.ForwardPath,
+		QueryMerge:   string(link.QueryMerge),
+		UTM:          utmToDTO(link.UTM),
+		ActiveFrom:   formatTime(link.ActiveFrom),
+		ActiveUntil:  formatTime(link.ActiveUntil),
+		MaxClicks:    link.MaxClicks,
+		ClicksUsed:   link.ClicksUsed,
+		FallbackURL:  link.FallbackURL,
+		Protected:    link.PasswordHash != "",
+		RequireSig:   link.RequireSignature,
+		Disabled:     link.Disabled,
+		TargetDown:   link.TargetDown,
+		Preview:      link.Preview,
+		OpenGraph:    openGraphToDTO(link.OpenGraph),
+		Tags:         link.Tags,
+		Owner:        link.Owner,
+		FolderID:     link.FolderID,
+		CreatedAt:    link.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func metadataToDTO(meta *domain.Metadata) *dto.Metadata {
+	if meta == nil {
+		return nil
+	}
+	return &dto.Metadata{
+		Title:       meta.Title,
+		Description: meta.Description,
+		FaviconURL:  meta.FaviconURL,
+		ImageURL:    meta.ImageURL,
+		FetchedAt:   formatTime(meta.FetchedAt),
+		Error:       meta.Error,
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseTimeBound parses an optional RFC 3339 window bound; "" clears it.
+func parseTimeBound(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func timeFromDTO(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func scheduleFromDTO(schedule []dto.ScheduleEntry) []domain.ScheduleEntry {
+	out := make([]domain.ScheduleEntry, len(schedule))
+	for i, entry := range schedule {
+		out[i] = domain.ScheduleEntry{
+			StartsAt: entry.StartsAt,
+			Target:   entry.Target,
+		}
+	}
+	return out
+}
+
+func scheduleToDTO(schedule []domain.ScheduleEntry) []dto.ScheduleEntry {
+	out := make([]dto.ScheduleEntry, len(schedule))
+	for i, entry := range schedule {
+		out[i] = dto.ScheduleEntry(entry)
+	}
+	return out
+}
+
+func utmFromDTO(utm *dto.UTM) domain.UTM {
+	if utm == nil {
+		return domain.UTM{}
+	}
+	return domain.UTM{
+		Source:   utm.Source,
+		Medium:   utm.Medium,
+		Campaign: utm.Campaign,
+		Term:     utm.Term,
+		Content:  utm.Content,
+	}
+}
+
+func utmToDTO(utm domain.UTM) *dto.UTM {
+	if utm.IsZero() {
+		return nil
+	}
+	return &dto.UTM{
+		Source:   utm.Source,
+		Medium:   utm.Medium,
+		Campaign: utm.Campaign,
+		Term:     utm.Term,
+		Content:  utm.Content,
+	}
+}
+
+func rulesFromDTO(rules []dto.Rule) []domain.Rule {
+	out := make([]domain.Rule, len(rules))
+	for i, rule := range rules {
+		out[i] = domain.Rule{
+			Priority: rule.Priority,
+			OS:       rule.OS,
+			Device:   rule.Device,
+			Country:  rule.Country,
+			Target:   rule.Target,
+		}
+	}
+	return out
+}
+
+func rulesToDTO(rules []domain.Rule) []dto.Rule {
+	out := make([]dto.Rule, len(rules))
+	for i, rule := range rules {
+		out[i] = dto.Rule{
+			Priority: rule.Priority,
+			OS:       rule.OS,
+			Device:   rule.Device,
+			Country:  rule.Country,
+			Target:   rule.Target,
+		}
+	}
+	return out
+}
+
+// localesToDTO never returns nil so that an empty map encodes as {}.
+func localesToDTO(locales map[string]string) map[string]string {
+	if locales == nil {
+		return map[string]string{}
+	}
+	return locales
+}
+
+func splitFromDTO(split *dto.Split) domain.Split {
+	if split == nil {
+		return domain.Split{}
+	}
+	out := domain.Split{
+		Rotation: domain.Rotation(split.Rotation),
+		Sticky:   split.Sticky,
+		Variants: make([]domain.Variant, len(split.Variants)),
+	}
+	for i, v := range split.Variants {
+		out.Variants[i] = domain.Variant{
+			Name:   v.Name,
+			Target: v.Target,
+			Weight: v.Weight,
+		}
+	}
+	return out
+}
+
+func splitToDTO(split *domain.Split) dto.Split {
+	out := dto.Split{
+		Rotation: string(split.Rotation),
+		Sticky:   split.Sticky,
+		Variants: make([]dto.Variant, len(split.Variants)),
+	}
+	for i, v := range split.Variants {
+		out.Variants[i] = dto.Variant{
+			ID:     v.ID,
+			Name:   v.Name,
+			Target: v.Target,
+			Weight: v.Weight,
+		}
+	}
+	return out
+}
+
+const (
+	variantCookiePrefix = "sv_"
+	variantCookieMaxAge = 30 * 24 * time.Hour
+)
+
+// stickyVariant returns the variant ID remembered in the visitor's cookie.
+func stickyVariant(r *http.Request, alias string) int64 {
+	cookie, err := r.Cookie(variantCookiePrefix + alias)
+	if err != nil {
+		return 0
+	}
+	id, err := strconv.ParseInt(cookie.Value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func setStickyVariant(w http.ResponseWriter, alias string, variant *domain.Variant) {
+	http.SetCookie(w, &http.Cookie{
+		Name:  variantCookiePrefix + alias,
+		Value: strconv.FormatInt(variant.ID, 10),
+		// Scans and clicks are the same visitor: the cookie covers both
+		// entry paths.
+		Path:     "/",
+		MaxAge:   int(variantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *URLHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.usecase.ListTags(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("list tags failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.TagListResponse{Tags: make([]dto.Tag, len(tags))}
+	for i, tag := range tags {
+		resp.Tags[i] = dto.Tag{Name: tag.Name, Links: tag.Links}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req dto.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.usecase.CreateTag(r.Context(), req.Name)
+	if err != nil {
+		h.sendTagError(w, r, err, "create tag failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dto.Tag{Name: tag.Name, Links: tag.Links}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+// RenameTag renames a tag on all links carrying it.
+func (h *URLHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "tag")
+
+	var req dto.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usecase.RenameTag(r.Context(), name, req.Name); err != nil {
+		h.sendTagError(w, r, err, "rename tag failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *URLHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.DeleteTag(r.Context(), chi.URLParam(r, "tag")); err != nil {
+		h.sendTagError(w, r, err, "delete tag failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *URLHandler) sendTagError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case isValidationError(err):
+		h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrTagExists):
+		h.sendJSONError(w, "tag already exists", http.StatusConflict)
+	case errors.Is(err, usecase.ErrNotFound):
+		h.sendJSONError(w, "tag not found", http.StatusNotFound)
+	default:
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("tag", chi.URLParam(r, "tag")).Msg(msg)
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *URLHandler) ReplaceTags(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var req dto.ReplaceTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.usecase.ReplaceLinkTags(r.Context(), alias, req.Tags)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("replace tags failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.TagsResponse{Tags: tags}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+// AssignTags adds and removes tags on many links at once. Aliases that do
+// not exist are reported and skipped.
+func (h *URLHandler) AssignTags(w http.ResponseWriter, r *http.Request) {
+	var req dto.AssignTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	missing, err := h.usecase.AssignTags(r.Context(), req.Aliases, req.Add, req.Remove)
+	if err != nil {
+		if isValidationError(err) || errors.Is(err, usecase.ErrInvalidBatch) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("assign tags failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	aliases := make(map[string]bool, len(req.Aliases))
+	for _, alias := range req.Aliases {
+		aliases[alias] = true
+	}
+	resp := dto.AssignTagsResponse{
+		Updated:  len(aliases) - len(missing),
+		NotFound: missing,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
@@ -1,35 +1,102 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"url-shortener-wb/internal/domain"
 	"url-shortener-wb/internal/http-server/handler/dto"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/pages"
+	"url-shortener-wb/internal/qr"
 	"url-shortener-wb/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/wb-go/wbf/zlog"
 )
 
+var validationErrors = []error{
+	usecase.ErrInvalidURL,
+	usecase.ErrInvalidAlias,
+	usecase.ErrInvalidRedirectType,
+	usecase.ErrInvalidQueryMerge,
+	usecase.ErrInvalidUTM,
+	usecase.ErrInvalidRule,
+	usecase.ErrInvalidLocale,
+	usecase.ErrInvalidVariant,
+	usecase.ErrInvalidSchedule,
+	usecase.ErrInvalidClickLimit,
+	usecase.ErrInvalidPassword,
+	usecase.ErrInvalidSignatureTTL,
+	usecase.ErrInvalidOpenGraph,
+	usecase.ErrInvalidTag,
+	usecase.ErrInvalidOwner,
+	usecase.ErrInvalidFilter,
+	usecase.ErrInvalidFolder,
+}
+
+func isValidationError(err error) bool {
+	for _, target := range validationErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectError describes how a failed redirect is shown: Message is the
+// JSON error for API clients, Title and Detail fill the HTML page.
+type redirectError struct {
+	err     error
+	status  int
+	message string
+	title   string
+	detail  string
+}
+
+var redirectErrors = []redirectError{
+	{usecase.ErrNotFound, http.StatusNotFound, "url not found",
+		"Link not found", "This short link does not exist. Check that it was copied completely."},
+	{usecase.ErrInvalidAlias, http.StatusNotFound, "url not found",
+		"Link not found", "This short link does not exist. Check that it was copied completely."},
+	{usecase.ErrLinkNotActive, http.StatusNotFound, "url not found",
+		"Link not found", "This short link does not exist. Check that it was copied completely."},
+	{usecase.ErrLinkExpired, http.StatusGone, "link has expired",
+		"Link expired", "This short link has expired and no longer leads anywhere."},
+	{usecase.ErrLinkExhausted, http.StatusGone, "link has reached its click limit",
+		"Link used up", "This short link has reached its click limit."},
+	{usecase.ErrLinkDisabled, http.StatusGone, "link is disabled",
+		"Link disabled", "This short link has been disabled by its owner."},
+	{usecase.ErrInvalidSignature, http.StatusForbidden, "invalid link signature",
+		"Invalid link", "This link is incomplete or has been tampered with."},
+	{usecase.ErrSignatureExpired, http.StatusGone, "signed link has expired",
+		"Link expired", "This signed link has expired. Ask the sender for a new one."},
+}
+
 type URLHandler struct {
 	usecase     URLUsecase
 	analyticsUC AnalyticsUsecase
+	pages       *pages.Renderer
+	qr          *qr.Encoder
 	logger      *zlog.Zerolog
 }
 
 func NewURLHandler(
 	usecase URLUsecase,
 	analyticsUC AnalyticsUsecase,
+	pages *pages.Renderer,
+	qr *qr.Encoder,
 	logger *zlog.Zerolog,
 ) *URLHandler {
 	return &URLHandler{
 		usecase:     usecase,
 		analyticsUC: analyticsUC,
+		pages:       pages,
+		qr:          qr,
 		logger:      logger,
 	}
 }
@@ -46,9 +113,9 @@ func (h *URLHandler) CreateShortURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	alias, err := h.usecase.CreateShortURL(r.Context(), req.URL, req.Custom)
+	alias, err := h.usecase.CreateShortURL(r.Context(), createInputFromDTO(req))
 	if err != nil {
-		if errors.Is(err, usecase.ErrInvalidURL) || errors.Is(err, usecase.ErrInvalidAlias) {
+		if isValidationError(err) {
 			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -58,61 +125,512 @@ func (h *URLHandler) CreateShortURL(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		h.logger.Error().Err(err).Msg("create short url failed")
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("create short url failed")
 		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	scheme := "http"
-	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
-		scheme = "https"
-	}
-	shortURL := fmt.Sprintf("%s://%s/s/%s", scheme, r.Host, alias)
-
 	resp := dto.CreateShortURLResponse{
-		ShortURL: shortURL,
+		ShortURL: shortURL(r, alias),
 		Alias:    alias,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		h.logger.Error().Err(err).Msg("failed to encode response")
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
 	}
 }
 
-func (h *URLHandler) RedirectToOriginal(w http.ResponseWriter, r *http.Request) {
+func createInputFromDTO(req dto.CreateShortURLRequest) domain.CreateURLInput {
+	return domain.CreateURLInput{
+		OriginalURL:      req.URL,
+		CustomAlias:      req.Custom,
+		RedirectType:     domain.RedirectType(req.RedirectType),
+		ForwardQuery:     req.ForwardQuery,
+		ForwardPath:      req.ForwardPath,
+		QueryMerge:       domain.QueryMerge(req.QueryMerge),
+		UTM:              utmFromDTO(req.UTM),
+		Rules:            rulesFromDTO(req.Rules),
+		Locales:          req.Locales,
+		Split:            splitFromDTO(req.Split),
+		ActiveFrom:       timeFromDTO(req.ActiveFrom),
+		ActiveUntil:      timeFromDTO(req.ActiveUntil),
+		Schedule:         scheduleFromDTO(req.Schedule),
+		MaxClicks:        req.MaxClicks,
+		FallbackURL:      req.FallbackURL,
+		Password:         req.Password,
+		RequireSignature: req.RequireSig,
+		Preview:          req.Preview,
+		OpenGraph:        openGraphFromDTO(req.OpenGraph),
+		Tags:             req.Tags,
+		Owner:            req.Owner,
+		FolderID:         req.FolderID,
+	}
+}
+
+func (h *URLHandler) GetLink(w http.ResponseWriter, r *http.Request) {
 	alias := chi.URLParam(r, "alias")
-	if alias == "" {
-		h.sendJSONError(w, "invalid url", http.StatusBadRequest)
+
+	link, meta, err := h.usecase.GetURL(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get link failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	userAgent := r.UserAgent()
-	ip := r.RemoteAddr
-	if colon := strings.LastIndex(ip, ":"); colon != -1 {
-		ip = ip[:colon]
+	resp := linkResponse(r, link)
+	resp.Metadata = metadataToDTO(meta)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
 	}
+}
+
+func (h *URLHandler) UpdateLink(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
 
-	originalURL, err := h.usecase.GetOriginalURL(r.Context(), alias)
+	var req dto.UpdateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var in domain.UpdateURLInput
+	if req.RedirectType != nil {
+		t := domain.RedirectType(*req.RedirectType)
+		in.RedirectType = &t
+	}
+	in.ForwardQuery = req.ForwardQuery
+	in.ForwardPath = req.ForwardPath
+	if req.QueryMerge != nil {
+		m := domain.QueryMerge(*req.QueryMerge)
+		in.QueryMerge = &m
+	}
+	if req.ActiveFrom != nil {
+		t, err := parseTimeBound(*req.ActiveFrom)
+		if err != nil {
+			h.sendJSONError(w, "active_from must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		in.ActiveFrom = &t
+	}
+	if req.ActiveUntil != nil {
+		t, err := parseTimeBound(*req.ActiveUntil)
+		if err != nil {
+			h.sendJSONError(w, "active_until must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		in.ActiveUntil = &t
+	}
+	in.MaxClicks = req.MaxClicks
+	in.FallbackURL = req.FallbackURL
+	in.Password = req.Password
+	in.RequireSignature = req.RequireSig
+	in.Disabled = req.Disabled
+	in.Preview = req.Preview
+	if req.OpenGraph != nil {
+		og := openGraphFromDTO(req.OpenGraph)
+		in.OpenGraph = &og
+	}
+	in.FolderID = req.FolderID
+
+	link, err := h.usecase.UpdateURL(r.Context(), alias, in)
 	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if errors.Is(err, usecase.ErrNotFound) || errors.Is(err, usecase.ErrInvalidAlias) {
 			h.sendJSONError(w, "url not found", http.StatusNotFound)
 			return
 		}
-		h.logger.Error().Err(err).Str("alias", alias).Msg("get original url failed")
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("update link failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(linkResponse(r, link)); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	rules, err := h.usecase.GetRules(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get rules failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.RulesResponse{Rules: rulesToDTO(rules)}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) ReplaceRules(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var req dto.ReplaceRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.usecase.ReplaceRules(r.Context(), alias, rulesFromDTO(req.Rules))
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("replace rules failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.RulesResponse{Rules: rulesToDTO(rules)}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) GetLocales(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	locales, err := h.usecase.GetLocales(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get locales failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.LocalesResponse{Locales: localesToDTO(locales)}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) ReplaceLocales(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var req dto.ReplaceLocalesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	locales, err := h.usecase.ReplaceLocales(r.Context(), alias, req.Locales)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("replace locales failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.LocalesResponse{Locales: localesToDTO(locales)}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) GetSplit(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	split, err := h.usecase.GetSplit(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get split failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(splitToDTO(split)); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) ReplaceSplit(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var req dto.Split
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	split, err := h.usecase.ReplaceSplit(r.Context(), alias, splitFromDTO(&req))
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("replace split failed")
 		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	go func() {
-		ctx := context.Background()
-		if err := h.analyticsUC.RecordClick(ctx, alias, userAgent, ip); err != nil {
-			h.logger.Warn().Err(err).Str("alias", alias).Msg("failed to record click")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(splitToDTO(split)); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	schedule, err := h.usecase.GetSchedule(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
 		}
-	}()
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get schedule failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
 
-	http.Redirect(w, r, originalURL, http.StatusTemporaryRedirect)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.ScheduleResponse{Schedule: scheduleToDTO(schedule)}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) ReplaceSchedule(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var req dto.ReplaceScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.usecase.ReplaceSchedule(r.Context(), alias, scheduleFromDTO(req.Schedule))
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("replace schedule failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.ScheduleResponse{Schedule: scheduleToDTO(schedule)}); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) SignLink(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var req dto.SignLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendJSONError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil {
+		h.sendJSONError(w, "ttl must be a duration such as 24h", http.StatusBadRequest)
+		return
+	}
+
+	params, expires, err := h.usecase.SignLink(r.Context(), alias, ttl)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			h.sendJSONError(w, "url not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, usecase.ErrSigningDisabled) {
+			h.sendJSONError(w, "link signing is not configured", http.StatusServiceUnavailable)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("sign link failed")
+		h.sendJSONError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.SignLinkResponse{
+		URL:       shortURL(r, alias) + "?" + params.Encode(),
+		ExpiresAt: expires.UTC().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Msg("failed to encode response")
+	}
+}
+
+func (h *URLHandler) RedirectToOriginal(w http.ResponseWriter, r *http.Request) {
+	h.visit(w, r, domain.ChannelLink)
+}
+
+// RedirectFromQR serves the /q/ entry encoded in QR codes, so that scans
+// show up separately in analytics.
+func (h *URLHandler) RedirectFromQR(w http.ResponseWriter, r *http.Request) {
+	h.visit(w, r, domain.ChannelQR)
+}
+
+func (h *URLHandler) visit(w http.ResponseWriter, r *http.Request, channel domain.Channel) {
+	// A trailing "+" asks for a preview of where the link goes.
+	alias, preview := strings.CutSuffix(chi.URLParam(r, "alias"), "+")
+	if alias == "" {
+		h.sendJSONError(w, "invalid url", http.StatusBadRequest)
+		return
+	}
+
+	userAgent := r.UserAgent()
+	ip := clientIP(r)
+
+	redirect, err := h.usecase.GetOriginalURL(r.Context(), domain.RedirectRequest{
+		Alias:          alias,
+		PathSuffix:     chi.URLParam(r, "*"),
+		Query:          r.URL.Query(),
+		UserAgent:      userAgent,
+		IP:             ip,
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		StickyVariant:  stickyVariant(r, alias),
+		UnlockToken:    unlockToken(r, alias),
+		Preview:        preview,
+		Head:           r.Method == http.MethodHead,
+	})
+	if err != nil {
+		if errors.Is(err, usecase.ErrPasswordRequired) {
+			if wantsHTML(w, r) {
+				h.renderPasswordForm(w, r, http.StatusOK, "")
+			} else {
+				h.sendJSONError(w, "password required", http.StatusUnauthorized)
+			}
+			return
+		}
+		h.sendRedirectError(w, r, alias, err)
+		return
+	}
+
+	if !redirect.Link.OpenGraph.IsZero() {
+		w.Header().Add("Vary", "User-Agent")
+	}
+	if redirect.Unfurl != nil {
+		h.renderUnfurl(w, r, alias, redirect.Unfurl)
+		return
+	}
+	if redirect.Fallback {
+		h.redirect(w, r, redirect)
+		return
+	}
+	if redirect.Preview {
+		h.renderPreview(w, r, alias, channel, redirect)
+		return
+	}
+
+	click := domain.Click{
+		URLID:     redirect.Link.ID,
+		UserAgent: userAgent,
+		IPAddress: ip,
+		Locale:    redirect.Locale,
+		Channel:   channel,
+	}
+	if redirect.Rule != nil {
+		click.MatchedRule = redirect.Rule.Label()
+	}
+	if redirect.Variant != nil {
+		click.Variant = redirect.Variant.Name
+	}
+	if !redirect.Probe {
+		h.analyticsUC.TrackClick(r.Context(), alias, click)
+	}
+
+	if len(redirect.Link.Locales) > 0 {
+		w.Header().Add("Vary", "Accept-Language")
+	}
+	if redirect.Variant != nil && redirect.Link.Split.Sticky {
+		setStickyVariant(w, alias, redirect.Variant)
+	}
+	h.redirect(w, r, redirect)
+}
+
+// sendRedirectError answers a failed visit: browsers get a branded page,
+// API clients the usual JSON error.
+func (h *URLHandler) sendRedirectError(w http.ResponseWriter, r *http.Request, alias string, err error) {
+	for _, e := range redirectErrors {
+		if errors.Is(err, e.err) {
+			h.sendPageError(w, r, e.status, e.message, pages.Data{Title: e.title, Message: e.detail})
+			return
+		}
+	}
+
+	logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("alias", alias).Msg("get original url failed")
+	h.sendPageError(w, r, http.StatusInternalServerError, "internal server error", pages.Data{
+		Title:   "Something went wrong",
+		Message: "We could not open this link. Please try again in a moment.",
+	})
+}
+
+func (h *URLHandler) sendPageError(w http.ResponseWriter, r *http.Request, status int, message string, data pages.Data) {
+	if !wantsHTML(w, r) {
+		h.sendJSONError(w, message, status)
+		return
+	}
+	h.renderPage(w, r, pages.Status, status, data)
+}
+
+// wantsHTML negotiates between an HTML page and JSON; either way the
+// response depends on Accept.
+func wantsHTML(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Add("Vary", "Accept")
+	return pages.WantsHTML(r)
+}
+
+func (h *URLHandler) renderPage(w http.ResponseWriter, r *http.Request, page string, status int, data pages.Data) {
+	if err := h.pages.Render(w, r, page, status, data); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error().Err(err).Str("page", page).Msg("failed to render page")
+		h.sendJSONError(w, http.StatusText(status), status)
+	}
 }
 
 func (h *URLHandler) sendJSONError(w http.ResponseWriter, message string, statusCode int) {
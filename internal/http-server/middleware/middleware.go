@@ -1,20 +1,266 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand/v2"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
 	"github.com/wb-go/wbf/zlog"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func LoggingMiddleware(next http.Handler) http.Handler {
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,128}$`)
+
+// RequestIDMiddleware propagates a valid incoming X-Request-ID or generates a
+// new one, and attaches a logger carrying it to the request context.
+func RequestIDMiddleware(logger *zlog.Zerolog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !requestIDRegex.MatchString(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			lctx := logger.With().Str("request_id", id)
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				lctx = lctx.Str("trace_id", sc.TraceID().String())
+			}
+			reqLogger := lctx.Logger()
+
+			ctx := logging.WithRequestID(r.Context(), id)
+			ctx = logging.WithLogger(ctx, &reqLogger)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ParseTrustedProxies reads proxy addresses given as IPs or CIDR ranges.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(v); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", v)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// RealIPMiddleware replaces RemoteAddr with the client address when the
+// request comes through a trusted proxy. X-Forwarded-For is read from the
+// right, skipping trusted proxies, so a client cannot choose its address by
+// sending the header itself.
+func RealIPMiddleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(s string) bool {
+		addr, err := netip.ParseAddr(strings.TrimSpace(s))
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil || !isTrusted(host) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop := strings.TrimSpace(hops[i])
+				if hop == "" || isTrusted(hop) {
+					continue
+				}
+				if addr, err := netip.ParseAddr(hop); err == nil {
+					r2 := r.Clone(r.Context())
+					r2.RemoteAddr = net.JoinHostPort(addr.Unmap().String(), "0")
+					r = r2
+				}
+				break
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+type LoggingOptions struct {
+	// SampleRate is the share of successful requests that are logged.
+	// Requests answered with 4xx/5xx are always logged.
+	SampleRate float64
+	// RedactParams lists query parameters whose values are masked.
+	RedactParams []string
+}
+
+func LoggingMiddleware(opts LoggingOptions) func(http.Handler) http.Handler {
+	redact := make(map[string]struct{}, len(opts.RedactParams))
+	for _, p := range opts.RedactParams {
+		redact[strings.ToLower(strings.TrimSpace(p))] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := wrapResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			if rw.status < http.StatusBadRequest && opts.SampleRate < 1 && mathrand.Float64() >= opts.SampleRate {
+				return
+			}
+
+			logger := logging.FromContext(r.Context(), &zlog.Logger)
+			event := logger.Info()
+			if rw.status >= http.StatusInternalServerError {
+				event = logger.Error()
+			} else if rw.status >= http.StatusBadRequest {
+				event = logger.Warn()
+			}
+
+			event.
+				Str("method", r.Method).
+				Str("url", redactURL(r.URL, redact)).
+				Int("status", rw.status).
+				Int("bytes", rw.bytes).
+				Str("remote_addr", r.RemoteAddr).
+				Str("user_agent", r.UserAgent()).
+				Dur("duration", time.Since(start)).
+				Msg("Request handled")
+		})
+	}
+}
+
+func redactURL(u *url.URL, redact map[string]struct{}) string {
+	if u.RawQuery == "" || len(redact) == 0 {
+		return u.String()
+	}
+
+	query := u.Query()
+	for key, values := range query {
+		if _, ok := redact[strings.ToLower(key)]; !ok {
+			continue
+		}
+		for i := range values {
+			values[i] = "REDACTED"
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// TracingMiddleware starts a server span for every request, continuing the
+// trace from incoming W3C trace context headers.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			switch r.URL.Path {
+			case "/metrics", "/healthz", "/readyz":
+				return false
+			}
+			return true
+		}),
+	)
+}
+
+// RouteSpanMiddleware names the server span after the matched chi route.
+// Like MetricsMiddleware it must be mounted on the router.
+func RouteSpanMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
 		next.ServeHTTP(w, r)
-		zlog.Logger.Info().
-			Str("method", r.Method).
-			Str("url", r.URL.String()).
-			Dur("duration", time.Since(start)).
-			Msg("Request handled")
+
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || rctx.RoutePattern() == "" {
+			return
+		}
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + rctx.RoutePattern())
+		span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+	})
+}
+
+// MetricsMiddleware must be mounted on the chi router so that the matched
+// route pattern is available once the request has been served.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := wrapResponseWriter(w)
+		next.ServeHTTP(rw, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := strconv.Itoa(rw.status)
+
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
 	})
 }
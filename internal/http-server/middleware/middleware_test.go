@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", "fd00::/8", ""})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:51000",
+			want:       "203.0.113.7:51000",
+		},
+		{
+			name:       "spoofed header from untrusted peer",
+			remoteAddr: "203.0.113.7:51000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "203.0.113.7:51000",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "198.51.100.1:0",
+		},
+		{
+			name:       "spoofed hop before the real client",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"1.2.3.4, 198.51.100.1"},
+			want:       "198.51.100.1:0",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"1.2.3.4, 198.51.100.1, 192.168.1.1, 10.1.1.1"},
+			want:       "198.51.100.1:0",
+		},
+		{
+			name:       "repeated headers",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"1.2.3.4", "198.51.100.1"},
+			want:       "198.51.100.1:0",
+		},
+		{
+			name:       "spoofed trusted address",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"198.51.100.1, 10.9.9.9"},
+			want:       "198.51.100.1:0",
+		},
+		{
+			name:       "garbage hop",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"198.51.100.1, not-an-ip"},
+			want:       "10.0.0.5:40000",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"10.1.1.1"},
+			want:       "10.0.0.5:40000",
+		},
+		{
+			name:       "trusted proxy without header",
+			remoteAddr: "10.0.0.5:40000",
+			want:       "10.0.0.5:40000",
+		},
+		{
+			name:       "ipv6 client",
+			remoteAddr: "[fd00::1]:40000",
+			forwarded:  []string{"2001:db8::7"},
+			want:       "[2001:db8::7]:0",
+		},
+		{
+			name:       "ipv4-mapped client",
+			remoteAddr: "10.0.0.5:40000",
+			forwarded:  []string{"::ffff:198.51.100.1"},
+			want:       "198.51.100.1:0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIPMiddleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/s/abc123", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalid(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "proxy.local"}); err == nil {
+		t.Error("ParseTrustedProxies() error = nil, want an error")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"url-shortener-wb/internal/http-server/handler"
+	"url-shortener-wb/internal/http-server/middleware"
+	"url-shortener-wb/internal/metrics"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,15 +15,65 @@ import (
 type Handler struct {
 	UrlH       *handler.URLHandler
 	AnalyticsH *handler.AnalyticsHandler
+	HealthH    *handler.HealthHandler
 }
 
 func SetupRouter(h *Handler) http.Handler {
 	r := chi.NewRouter()
+	r.Use(middleware.MetricsMiddleware)
+	r.Use(middleware.RouteSpanMiddleware)
+
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", h.HealthH.Liveness)
+	r.Get("/readyz", h.HealthH.Readiness)
 
 	r.Post("/shorten", h.UrlH.CreateShortURL)
 	r.Get("/s/{alias}", h.UrlH.RedirectToOriginal)
+	r.Get("/s/{alias}/*", h.UrlH.RedirectToOriginal)
+	r.Head("/s/{alias}", h.UrlH.RedirectToOriginal)
+	r.Head("/s/{alias}/*", h.UrlH.RedirectToOriginal)
+	r.Post("/s/{alias}", h.UrlH.UnlockLink)
+	r.Post("/s/{alias}/*", h.UrlH.UnlockLink)
+	r.Get("/q/{alias}", h.UrlH.RedirectFromQR)
+	r.Get("/q/{alias}/*", h.UrlH.RedirectFromQR)
+	r.Head("/q/{alias}", h.UrlH.RedirectFromQR)
+	r.Head("/q/{alias}/*", h.UrlH.RedirectFromQR)
+	r.Post("/q/{alias}", h.UrlH.UnlockLink)
+	r.Post("/q/{alias}/*", h.UrlH.UnlockLink)
 	r.Get("/analytics/{alias}", h.AnalyticsH.GetAnalytics)
 
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/links", h.UrlH.ListLinks)
+		r.Post("/links/batch", h.UrlH.CreateShortURLs)
+		r.Post("/links/tags", h.UrlH.AssignTags)
+		r.Post("/links/import", h.UrlH.ImportLinks)
+		r.Get("/links/export", h.UrlH.ExportLinks)
+		r.Get("/links/{alias}", h.UrlH.GetLink)
+		r.Patch("/links/{alias}", h.UrlH.UpdateLink)
+		r.Get("/links/{alias}/rules", h.UrlH.GetRules)
+		r.Put("/links/{alias}/rules", h.UrlH.ReplaceRules)
+		r.Get("/links/{alias}/locales", h.UrlH.GetLocales)
+		r.Put("/links/{alias}/locales", h.UrlH.ReplaceLocales)
+		r.Get("/links/{alias}/variants", h.UrlH.GetSplit)
+		r.Put("/links/{alias}/variants", h.UrlH.ReplaceSplit)
+		r.Get("/links/{alias}/schedule", h.UrlH.GetSchedule)
+		r.Put("/links/{alias}/schedule", h.UrlH.ReplaceSchedule)
+		r.Put("/links/{alias}/tags", h.UrlH.ReplaceTags)
+		r.Post("/links/{alias}/signed", h.UrlH.SignLink)
+		r.Get("/links/{alias}/qr", h.UrlH.GetQR)
+		r.Get("/campaigns/{campaign}/stats", h.AnalyticsH.GetCampaignStats)
+		r.Get("/tags", h.UrlH.ListTags)
+		r.Post("/tags", h.UrlH.CreateTag)
+		r.Patch("/tags/{tag}", h.UrlH.RenameTag)
+		r.Delete("/tags/{tag}", h.UrlH.DeleteTag)
+		r.Get("/tags/{tag}/stats", h.AnalyticsH.GetTagStats)
+		r.Get("/folders", h.UrlH.ListFolders)
+		r.Post("/folders", h.UrlH.CreateFolder)
+		r.Get("/folders/{id}", h.UrlH.GetFolder)
+		r.Patch("/folders/{id}", h.UrlH.UpdateFolder)
+		r.Delete("/folders/{id}", h.UrlH.DeleteFolder)
+	})
+
 	staticDir := "./static"
 	fs := http.FileServer(http.Dir(staticDir))
 	r.Handle("/static/*", http.StripPrefix("/static/", fs))
@@ -37,6 +89,7 @@ func SetupRouter(h *Handler) http.Handler {
 	r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasPrefix(r.URL.Path, "/api/") ||
 			strings.HasPrefix(r.URL.Path, "/s/") ||
+			strings.HasPrefix(r.URL.Path, "/q/") ||
 			strings.HasPrefix(r.URL.Path, "/analytics/") ||
 			strings.HasPrefix(r.URL.Path, "/static/") {
 			http.NotFound(w, r)
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+	requestIDKey
+)
+
+func WithLogger(ctx context.Context, logger *zlog.Zerolog) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the request-scoped logger, or fallback when the
+// context does not carry one (background jobs, tests).
+func FromContext(ctx context.Context, fallback *zlog.Zerolog) *zlog.Zerolog {
+	if logger, ok := ctx.Value(loggerKey).(*zlog.Zerolog); ok && logger != nil {
+		return logger
+	}
+	return fallback
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
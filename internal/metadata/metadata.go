@@ -0,0 +1,248 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxTextLength = 300
+	maxURLLength  = 2048
+)
+
+var ErrForbiddenAddress = errors.New("destination resolves to a private address")
+
+type Options struct {
+	Timeout  time.Duration
+	MaxBytes int64
+	// AllowPrivate lets the fetcher reach loopback and private networks;
+	// only meant for local development.
+	AllowPrivate bool
+}
+
+// Fetcher downloads destination pages and extracts what a link preview
+// needs. Addresses are checked when connecting, after DNS resolution, so a
+// hostname cannot be pointed at an internal service.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+func NewFetcher(opts Options) *Fetcher {
+	return &Fetcher{
+		client:   NewClient(opts.Timeout, opts.AllowPrivate),
+		maxBytes: opts.MaxBytes,
+	}
+}
+
+// NewClient returns an HTTP client for requests to user-supplied URLs. It
+// follows up to five http(s) redirects and, unless allowPrivate is set,
+// refuses to connect to private addresses, redirect hops included.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = denyPrivate
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy: the address check must see the real destination.
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return checkScheme(req.URL)
+		},
+	}
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, target string) (*domain.Metadata, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "url-shortener-preview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", u.Host, resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("failed to fetch %s: unsupported content type %q", u.Host, mediaType)
+	}
+
+	return parse(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL), nil
+}
+
+// parse reads the document head. Documents are frequently malformed, so a
+// tokenizer error simply ends the scan with whatever was found. Relative
+// links are resolved against base, the URL the page was served from.
+func parse(r io.Reader, base *url.URL) *domain.Metadata {
+	var (
+		meta                 domain.Metadata
+		ogTitle, ogDesc, ico string
+	)
+	z := html.NewTokenizer(r)
+scan:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			break scan
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				if meta.Title == "" && tt == html.StartTagToken && z.Next() == html.TextToken {
+					meta.Title = clean(string(z.Text()))
+				}
+			case "meta":
+				attrs := attributes(z, hasAttr)
+				key := strings.ToLower(attrs["property"])
+				if key == "" {
+					key = strings.ToLower(attrs["name"])
+				}
+				content := attrs["content"]
+				switch key {
+				case "description":
+					meta.Description = clean(content)
+				case "og:title":
+					ogTitle = clean(content)
+				case "og:description":
+					ogDesc = clean(content)
+				case "og:image", "og:image:url", "twitter:image":
+					if meta.ImageURL == "" {
+						meta.ImageURL = resolve(base, content)
+					}
+				}
+			case "link":
+				attrs := attributes(z, hasAttr)
+				if ico == "" && isIconRel(attrs["rel"]) {
+					ico = resolve(base, attrs["href"])
+				}
+			case "body":
+				break scan
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				break scan
+			}
+		}
+	}
+
+	if meta.Title == "" {
+		meta.Title = ogTitle
+	}
+	if meta.Description == "" {
+		meta.Description = ogDesc
+	}
+	meta.FaviconURL = ico
+	if meta.FaviconURL == "" {
+		meta.FaviconURL = resolve(base, "/favicon.ico")
+	}
+	return &meta
+}
+
+func attributes(z *html.Tokenizer, more bool) map[string]string {
+	attrs := map[string]string{}
+	for more {
+		var key, value []byte
+		key, value, more = z.TagAttr()
+		attrs[string(key)] = string(value)
+	}
+	return attrs
+}
+
+func isIconRel(rel string) bool {
+	for _, v := range strings.Fields(strings.ToLower(rel)) {
+		if v == "icon" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns ref as an absolute http(s) URL, or "" if it is not one.
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || len(ref) > maxURLLength {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || checkScheme(u) != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// clean collapses whitespace and caps the length of extracted text.
+func clean(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxTextLength {
+		s = string(r[:maxTextLength])
+	}
+	return s
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// denyPrivate is a net.Dialer Control that refuses loopback, private,
+// link-local and shared addresses, cloud metadata endpoints included.
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
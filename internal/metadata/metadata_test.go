@@ -0,0 +1,243 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newServer serves body as HTML at every path.
+func newServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newLocalFetcher allows private addresses, since httptest listens on
+// loopback.
+func newLocalFetcher(opts Options) *Fetcher {
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = 1 << 20
+	}
+	opts.AllowPrivate = true
+	return NewFetcher(opts)
+}
+
+func TestFetchExtractsMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		title   string
+		desc    string
+		image   string
+		favicon string
+	}{
+		{
+			name: "title and description",
+			body: `<html><head>
+				<title>  Example
+					page </title>
+				<meta name="description" content="A short description">
+				<meta property="og:image" content="/img/cover.png">
+				<link rel="shortcut icon" href="/static/icon.png">
+			</head><body><title>ignored</title></body></html>`,
+			title:   "Example page",
+			desc:    "A short description",
+			image:   "/img/cover.png",
+			favicon: "/static/icon.png",
+		},
+		{
+			name: "open graph fallback",
+			body: `<html><head>
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content="OG description">
+				<meta name="twitter:image" content="https://cdn.example.com/a.png">
+			</head></html>`,
+			title:   "OG title",
+			desc:    "OG description",
+			image:   "https://cdn.example.com/a.png",
+			favicon: "/favicon.ico",
+		},
+		{
+			name: "non-http links are dropped",
+			body: `<html><head>
+				<title>Page</title>
+				<meta property="og:image" content="javascript:alert(1)">
+				<link rel="icon" href="data:image/png;base64,AAAA">
+			</head></html>`,
+			title:   "Page",
+			favicon: "/favicon.ico",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, tt.body)
+			absolute := func(ref string) string {
+				if strings.HasPrefix(ref, "/") {
+					return srv.URL + ref
+				}
+				return ref
+			}
+
+			meta, err := newLocalFetcher(Options{}).Fetch(context.Background(), srv.URL+"/page")
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if meta.Title != tt.title {
+				t.Errorf("Title = %q, want %q", meta.Title, tt.title)
+			}
+			if meta.Description != tt.desc {
+				t.Errorf("Description = %q, want %q", meta.Description, tt.desc)
+			}
+			if want := absolute(tt.image); meta.ImageURL != want {
+				t.Errorf("ImageURL = %q, want %q", meta.ImageURL, want)
+			}
+			if want := absolute(tt.favicon); meta.FaviconURL != want {
+				t.Errorf("FaviconURL = %q, want %q", meta.FaviconURL, want)
+			}
+		})
+	}
+}
+
+func TestFetchCapsTextLength(t *testing.T) {
+	srv := newServer(t, "<title>"+strings.Repeat("я", maxTextLength+50)+"</title>")
+
+	meta, err := newLocalFetcher(Options{}).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if n := len([]rune(meta.Title)); n != maxTextLength {
+		t.Errorf("title length = %d, want %d", n, maxTextLength)
+	}
+}
+
+func TestFetchStopsAtMaxBytes(t *testing.T) {
+	padding := "<!--" + strings.Repeat("x", 4096) + "-->"
+	srv := newServer(t, "<html><head>"+padding+"<title>Too far</title></head></html>")
+
+	meta, err := newLocalFetcher(Options{MaxBytes: 1024}).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if meta.Title != "" {
+		t.Errorf("Title = %q, want nothing past the size limit", meta.Title)
+	}
+}
+
+func TestFetchTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	_, err := newLocalFetcher(Options{Timeout: 100 * time.Millisecond}).Fetch(context.Background(), srv.URL)
+	if err == nil {
+		t.Fatal("Fetch() error = nil, want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fetch() took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestFetchRejectsUnsupportedResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "missing", http.StatusNotFound)
+			},
+		},
+		{
+			name: "not html",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"title":"no"}`)
+			},
+		},
+		{
+			name: "redirect to another scheme",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			t.Cleanup(srv.Close)
+
+			if _, err := newLocalFetcher(Options{}).Fetch(context.Background(), srv.URL); err == nil {
+				t.Error("Fetch() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestFetchRejectsPrivateAddresses(t *testing.T) {
+	srv := newServer(t, "<title>internal</title>")
+
+	f := NewFetcher(Options{Timeout: 2 * time.Second, MaxBytes: 1 << 20})
+	_, err := f.Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Fetch() error = %v, want %v", err, ErrForbiddenAddress)
+	}
+}
+
+func TestFetchRejectsUnsupportedScheme(t *testing.T) {
+	if _, err := newLocalFetcher(Options{}).Fetch(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Error("Fetch() error = nil, want an error")
+	}
+}
+
+func TestDenyPrivate(t *testing.T) {
+	tests := []struct {
+		address string
+		denied  bool
+	}{
+		{"127.0.0.1:80", true},
+		{"[::1]:443", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"100.64.0.1:80", true},
+		{"0.0.0.0:80", true},
+		{"[fe80::1]:80", true},
+		{"[fc00::1]:80", true},
+		{"224.0.0.1:80", true},
+		{"93.184.216.34:80", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := denyPrivate("tcp", tt.address, nil)
+			if denied := errors.Is(err, ErrForbiddenAddress); denied != tt.denied {
+				t.Errorf("denyPrivate(%q) = %v, want denied %v", tt.address, err, tt.denied)
+			}
+		})
+	}
+}
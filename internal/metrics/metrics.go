@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+const namespace = "url_shortener"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "URL cache lookups by result (hit, miss).",
+	}, []string{"result"})
+
+	Redirects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Total number of redirects served by redirect type (301, 302, 307, 308, meta, fallback).",
+	}, []string{"type"})
+
+	ClickQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "clicks",
+		Name:      "queue_depth",
+		Help:      "Number of clicks waiting to be recorded.",
+	})
+
+	ClicksDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "clicks",
+		Name:      "dropped_total",
+		Help:      "Clicks dropped because the queue was full or closed.",
+	})
+
+	ClicksRecorded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "clicks",
+		Name:      "recorded_total",
+		Help:      "Clicks processed by the pipeline by result (ok, error).",
+	}, []string{"result"})
+
+	MetadataFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "metadata",
+		Name:      "fetches_total",
+		Help:      "Background destination metadata fetches by result (ok, error, dropped).",
+	}, []string{"result"})
+
+	TargetChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "targets",
+		Name:      "checks_total",
+		Help:      "Link destination probes by result (up, down, error).",
+	}, []string{"result"})
+)
+
+func RegisterDB(db *dbpg.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db.Master, "master"))
+	for i, slave := range db.Slaves {
+		prometheus.MustRegister(collectors.NewDBStatsCollector(slave, fmt.Sprintf("slave_%d", i)))
+	}
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,207 @@
+package pages
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+)
+
+// Pages a Renderer can render. Each is a template file of the same name
+// wrapped in layout.html. Status serves every error page; the status code,
+// title and message come from Data.
+const (
+	Status       = "status"
+	Password     = "password"
+	Interstitial = "interstitial"
+	OpenGraph    = "opengraph"
+)
+
+var names = []string{Status, Password, Interstitial, OpenGraph}
+
+const layout = "layout.html"
+
+//go:embed templates/*.html
+var builtin embed.FS
+
+type Data struct {
+	Brand   string
+	Status  int
+	Title   string
+	Message string
+	// Action and Error are used by the password form.
+	Action string
+	Error  string
+	// Target and ContinueURL are used by the interstitial; Destination
+	// describes Target when known.
+	Target      string
+	ContinueURL string
+	Destination *domain.Metadata
+	// OpenGraph and URL fill the card served to social crawlers.
+	OpenGraph *domain.OpenGraph
+	URL       string
+}
+
+type set map[string]*template.Template
+
+// Renderer renders visitor-facing HTML pages. The built-in templates can be
+// overridden from a directory: dir/<name>.html replaces a page for every
+// host, dir/<host>/<name>.html for that host only. Overriding layout.html
+// rebrands all pages at once; pages add to <head> through a "head" block.
+type Renderer struct {
+	brand    string
+	defaults set
+	hosts    map[string]set
+}
+
+func New(dir, brand string) (*Renderer, error) {
+	base, err := fs.Sub(builtin, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []fs.FS{base}
+	if dir != "" {
+		layers = append(layers, os.DirFS(dir))
+	}
+
+	defaults, err := buildSet(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{brand: brand, defaults: defaults, hosts: map[string]set{}}
+	if dir == "" {
+		return r, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates dir %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		host := strings.ToLower(entry.Name())
+		hostSet, err := buildSet(append(layers, os.DirFS(filepath.Join(dir, entry.Name()))))
+		if err != nil {
+			return nil, fmt.Errorf("host %s: %w", host, err)
+		}
+		r.hosts[host] = hostSet
+	}
+	return r, nil
+}
+
+func buildSet(layers []fs.FS) (set, error) {
+	layoutSrc, err := lookup(layers, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	s := set{}
+	for _, name := range names {
+		src, err := lookup(layers, name+".html")
+		if err != nil {
+			return nil, err
+		}
+		t, err := template.New(name).Parse(layoutSrc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", layout, err)
+		}
+		if t, err = t.Parse(src); err != nil {
+			return nil, fmt.Errorf("failed to parse %s.html: %w", name, err)
+		}
+		s[name] = t
+	}
+	return s, nil
+}
+
+// lookup returns the file from the last layer that has it.
+func lookup(layers []fs.FS, name string) (string, error) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		b, err := fs.ReadFile(layers[i], name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("template %s not found", name)
+}
+
+func (rd *Renderer) Render(w http.ResponseWriter, r *http.Request, name string, status int, data Data) error {
+	s, ok := rd.hosts[host(r)]
+	if !ok {
+		s = rd.defaults
+	}
+	t, ok := s[name]
+	if !ok {
+		return fmt.Errorf("unknown page %s", name)
+	}
+
+	if data.Brand == "" {
+		data.Brand = rd.brand
+	}
+	data.Status = status
+
+	// Render fully first so that a failing template does not leave a
+	// half-written page behind a success status.
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render page %s: %w", name, err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func host(r *http.Request) string {
+	h := r.Host
+	if hostname, _, err := net.SplitHostPort(h); err == nil {
+		h = hostname
+	}
+	return strings.ToLower(h)
+}
+
+// WantsHTML reports whether the client prefers an HTML page to JSON.
+// Browsers list text/html explicitly; API clients and tools like curl send
+// application/json or */* and keep getting JSON.
+func WantsHTML(r *http.Request) bool {
+	htmlQ, jsonQ := -1.0, -1.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "text/html", "application/xhtml+xml":
+			htmlQ = max(htmlQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return htmlQ > 0 && htmlQ > jsonQ
+}
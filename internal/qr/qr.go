@@ -0,0 +1,262 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+const (
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 2048
+	DefaultMargin = 4
+	MaxMargin     = 16
+
+	// logoRatio is the share of the code width covered by the logo. Level H
+	// recovers up to 30% of damaged modules, which keeps a logo this size
+	// well within what scanners can read around.
+	logoRatio = 0.22
+)
+
+var (
+	ErrInvalidOptions = errors.New("invalid qr code options")
+	ErrNoLogo         = errors.New("no qr code logo configured")
+)
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+type Options struct {
+	Format Format
+	// Size is the width and height of the image in pixels.
+	Size int
+	// Margin is the quiet zone around the code, in modules.
+	Margin     int
+	ECC        string
+	Foreground color.Color
+	Background color.Color
+	Logo       bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       DefaultSize,
+		Margin:     DefaultMargin,
+		ECC:        "M",
+		Foreground: color.Black,
+		Background: color.White,
+	}
+}
+
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return fmt.Errorf("%w: format must be png or svg", ErrInvalidOptions)
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("%w: size must be between %d and %d", ErrInvalidOptions, MinSize, MaxSize)
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+	if _, ok := levels[o.ECC]; !ok {
+		return fmt.Errorf("%w: ecc must be one of L, M, Q, H", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// ParseColor parses "rrggbb" or "rrggbbaa", with or without a leading "#".
+func ParseColor(s string) (color.Color, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 8 {
+		return nil, fmt.Errorf("%w: colour must be rrggbb or rrggbbaa", ErrInvalidOptions)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: colour must be hexadecimal", ErrInvalidOptions)
+	}
+	if len(s) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// Encoder renders QR codes, optionally with the configured logo in the
+// centre.
+type Encoder struct {
+	logo image.Image
+	// logoPNG is the logo as base64 PNG for embedding in SVG.
+	logoPNG string
+}
+
+// NewEncoder loads the logo at logoPath; an empty path disables logos.
+func NewEncoder(logoPath string) (*Encoder, error) {
+	if logoPath == "" {
+		return &Encoder{}, nil
+	}
+
+	f, err := os.Open(logoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open qr logo %s: %w", logoPath, err)
+	}
+	defer f.Close()
+
+	logo, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode qr logo %s: %w", logoPath, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		return nil, fmt.Errorf("failed to encode qr logo: %w", err)
+	}
+	return &Encoder{logo: logo, logoPNG: base64.StdEncoding.EncodeToString(buf.Bytes())}, nil
+}
+
+// Encode renders content and returns the image with its content type.
+func (e *Encoder) Encode(content string, opts Options) ([]byte, string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, "", err
+	}
+	if opts.Logo && e.logo == nil {
+		return nil, "", ErrNoLogo
+	}
+
+	level := levels[opts.ECC]
+	if opts.Logo {
+		// The logo hides modules; only the highest level reliably makes up
+		// for them.
+		level = qrcode.Highest
+	}
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode qr code: %w", err)
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+	// Every module needs at least a pixel; a smaller image would crop the
+	// code or blur it beyond scanning.
+	if total := len(modules) + 2*opts.Margin; opts.Size < total {
+		return nil, "", fmt.Errorf("%w: size must be at least %d for this link and margin", ErrInvalidOptions, total)
+	}
+
+	if opts.Format == FormatSVG {
+		return e.svg(modules, opts), "image/svg+xml", nil
+	}
+	data, err := e.png(modules, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, "image/png", nil
+}
+
+func (e *Encoder) png(modules [][]bool, opts Options) ([]byte, error) {
+	n := len(modules)
+	total := n + 2*opts.Margin
+	scale := opts.Size / total
+	// Whole pixels per module keep edges sharp; the rest of the requested
+	// size is spread evenly around the code as extra quiet zone.
+	offset := (opts.Size - total*scale) / 2
+
+	img := image.NewNRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	fg := image.NewUniform(opts.Foreground)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			px := offset + (x+opts.Margin)*scale
+			py := offset + (y+opts.Margin)*scale
+			draw.Draw(img, image.Rect(px, py, px+scale, py+scale), fg, image.Point{}, draw.Src)
+		}
+	}
+
+	if opts.Logo {
+		side := int(float64(n*scale) * logoRatio)
+		x := (opts.Size - side) / 2
+		pad := max(scale, 2)
+		draw.Draw(img, image.Rect(x-pad, x-pad, x+side+pad, x+side+pad), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x, x, x+side, x+side), scaleImage(e.logo, side), image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *Encoder) svg(modules [][]bool, opts Options) []byte {
+	n := len(modules)
+	total := n + 2*opts.Margin
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, svgColor(opts.Background))
+	fmt.Fprintf(&b, `<path fill="%s" d="`, svgColor(opts.Foreground))
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if opts.Logo {
+		side := float64(n) * logoRatio
+		pos := (float64(total) - side) / 2
+		fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`,
+			pos-1, pos-1, side+2, side+2, svgColor(opts.Background))
+		fmt.Fprintf(&b, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/png;base64,%s"/>`,
+			pos, pos, side, side, e.logoPNG)
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+func svgColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", n.R, n.G, n.B, float64(n.A)/0xff)
+}
+
+// scaleImage resizes src to a side×side square with nearest-neighbour
+// sampling, which is good enough for a small logo.
+func scaleImage(src image.Image, side int) image.Image {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/side, b.Min.Y+y*b.Dy()/side))
+		}
+	}
+	return dst
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"url-shortener-wb/internal/domain"
 	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
 
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("url-shortener-wb/internal/repository/analytics/postgres")
+
 type AnalyticsRepository struct {
 	db      *dbpg.DB
 	urlRepo URLRepository
@@ -34,11 +41,17 @@ func NewAnalyticsRepository(
 	}
 }
 
-func (r *AnalyticsRepository) RecordClick(ctx context.Context, click *domain.Click) error {
-	_, err := r.db.ExecWithRetry(ctx, r.retries,
-		`INSERT INTO clicks (url_id, user_agent, ip_address, clicked_at)
-		VALUES ($1, $2, $3, $4)`,
-		click.URLID, click.UserAgent, click.IPAddress, click.ClickedAt,
+func (r *AnalyticsRepository) RecordClick(ctx context.Context, click *domain.Click) (err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsRepository.RecordClick", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", click.URLID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = r.db.ExecWithRetry(ctx, r.retries,
+		`INSERT INTO clicks (url_id, user_agent, ip_address, matched_rule, locale, variant, channel, clicked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		click.URLID, click.UserAgent, click.IPAddress, click.MatchedRule, click.Locale, click.Variant, click.Channel, click.ClickedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert click: %w", err)
@@ -46,7 +59,46 @@ func (r *AnalyticsRepository) RecordClick(ctx context.Context, click *domain.Cli
 	return nil
 }
 
-func (r *AnalyticsRepository) GetAnalytics(ctx context.Context, alias string) (*domain.AnalyticsReport, error) {
+// AddClickCounts adds to the click totals of links in one statement. It is
+// not retried because a retried addition could be applied twice. Totals of
+// links deleted in the meantime are dropped.
+func (r *AnalyticsRepository) AddClickCounts(ctx context.Context, counts map[int64]int64) (err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsRepository.AddClickCounts", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int("links.count", len(counts)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	ids := make([]int64, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	clicks := make([]int64, len(ids))
+	for i, id := range ids {
+		clicks[i] = counts[id]
+	}
+
+	_, err = r.db.Master.ExecContext(ctx,
+		`INSERT INTO url_click_counts (url_id, clicks)
+		SELECT c.url_id, c.clicks
+		FROM unnest($1::bigint[], $2::bigint[]) AS c(url_id, clicks)
+		JOIN urls u ON u.id = c.url_id
+		ON CONFLICT (url_id) DO UPDATE SET clicks = url_click_counts.clicks + EXCLUDED.clicks`,
+		pq.Array(ids), pq.Array(clicks))
+	if err != nil {
+		return fmt.Errorf("failed to add click counts: %w", err)
+	}
+	return nil
+}
+
+func (r *AnalyticsRepository) GetAnalytics(ctx context.Context, alias string) (_ *domain.AnalyticsReport, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsRepository.GetAnalytics", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	url, err := r.urlRepo.GetByAlias(ctx, alias)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
@@ -56,7 +108,7 @@ func (r *AnalyticsRepository) GetAnalytics(ctx context.Context, alias string) (*
 	}
 
 	rows, err := r.db.QueryWithRetry(ctx, r.retries,
-		`SELECT id, user_agent, ip_address, clicked_at
+		`SELECT id, user_agent, ip_address, matched_rule, locale, variant, channel, clicked_at
 		FROM clicks WHERE url_id = $1
 		ORDER BY clicked_at DESC
 		LIMIT 100`, url.ID)
@@ -68,7 +120,7 @@ func (r *AnalyticsRepository) GetAnalytics(ctx context.Context, alias string) (*
 	var clicks []domain.Click
 	for rows.Next() {
 		var click domain.Click
-		if err := rows.Scan(&click.ID, &click.UserAgent, &click.IPAddress, &click.ClickedAt); err != nil {
+		if err := rows.Scan(&click.ID, &click.UserAgent, &click.IPAddress, &click.MatchedRule, &click.Locale, &click.Variant, &click.Channel, &click.ClickedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan click row: %w", err)
 		}
 		click.URLID = url.ID
@@ -83,6 +135,8 @@ func (r *AnalyticsRepository) GetAnalytics(ctx context.Context, alias string) (*
 		DailyStats:     make(map[string]int),
 		MonthlyStats:   make(map[string]int),
 		UserAgentStats: make(map[string]int),
+		RuleStats:      make(map[string]int),
+		LocaleStats:    make(map[string]int),
 		Clicks:         clicks,
 	}
 
@@ -92,6 +146,189 @@ func (r *AnalyticsRepository) GetAnalytics(ctx context.Context, alias string) (*
 		report.DailyStats[date]++
 		report.MonthlyStats[month]++
 		report.UserAgentStats[click.UserAgent]++
+		if click.MatchedRule != "" {
+			report.RuleStats[click.MatchedRule]++
+		}
+		if click.Locale != "" {
+			report.LocaleStats[click.Locale]++
+		}
+	}
+
+	// Variants and channels are compared over all clicks, not just the
+	// recent sample.
+	report.VariantStats, err = r.countClicksBy(ctx,
+		`SELECT variant, COUNT(*) FROM clicks
+		WHERE url_id = $1 AND variant <> ''
+		GROUP BY variant`, url.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variant stats: %w", err)
+	}
+
+	report.ChannelStats, err = r.countClicksBy(ctx,
+		`SELECT channel, COUNT(*) FROM clicks
+		WHERE url_id = $1
+		GROUP BY channel`, url.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel stats: %w", err)
+	}
+
+	return report, nil
+}
+
+// countClicksBy runs a "SELECT key, COUNT(*) ... GROUP BY key" query with
+// one argument, such as a link ID or a tag name.
+func (r *AnalyticsRepository) countClicksBy(ctx context.Context, query string, arg any) (map[string]int, error) {
+	rows, err := r.db.QueryWithRetry(ctx, r.retries, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			key    string
+			clicks int
+		)
+		if err := rows.Scan(&key, &clicks); err != nil {
+			return nil, err
+		}
+		counts[key] = clicks
+	}
+	return counts, rows.Err()
+}
+
+func (r *AnalyticsRepository) GetCampaignStats(ctx context.Context, campaign string) (_ *domain.CampaignReport, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsRepository.GetCampaignStats", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("utm.campaign", campaign),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT u.alias, u.original_url, u.utm_source, u.utm_medium, COUNT(c.id)
+		FROM urls u
+		LEFT JOIN clicks c ON c.url_id = u.id
+		WHERE u.utm_campaign = $1
+		GROUP BY u.id
+		ORDER BY COUNT(c.id) DESC, u.alias`, campaign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query campaign links: %w", err)
+	}
+	defer rows.Close()
+
+	report := &domain.CampaignReport{
+		Campaign:    campaign,
+		DailyStats:  make(map[string]int),
+		SourceStats: make(map[string]int),
+		MediumStats: make(map[string]int),
+	}
+
+	for rows.Next() {
+		var link domain.CampaignLinkStats
+		if err := rows.Scan(&link.Alias, &link.OriginalURL, &link.Source, &link.Medium, &link.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign link row: %w", err)
+		}
+		report.Links = append(report.Links, link)
+		report.TotalClicks += link.Clicks
+		report.SourceStats[link.Source] += link.Clicks
+		report.MediumStats[link.Medium] += link.Clicks
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign links: %w", err)
+	}
+
+	if len(report.Links) == 0 {
+		return nil, fmt.Errorf("%w: no links for campaign %s", repo.ErrNotFound, campaign)
+	}
+
+	dailyRows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT to_char(c.clicked_at, 'YYYY-MM-DD'), COUNT(*)
+		FROM clicks c
+		JOIN urls u ON u.id = c.url_id
+		WHERE u.utm_campaign = $1
+		GROUP BY 1`, campaign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query campaign daily stats: %w", err)
+	}
+	defer dailyRows.Close()
+
+	for dailyRows.Next() {
+		var (
+			day    string
+			clicks int
+		)
+		if err := dailyRows.Scan(&day, &clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign daily row: %w", err)
+		}
+		report.DailyStats[day] = clicks
+	}
+	if err := dailyRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign daily stats: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetTagStats sums up the clicks of the links carrying tag.
+func (r *AnalyticsRepository) GetTagStats(ctx context.Context, tag string) (_ *domain.TagReport, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsRepository.GetTagStats", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("tag.name", tag),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT u.alias, u.original_url, COALESCE(cc.clicks, 0) AS clicks
+		FROM urls u
+		JOIN url_tags ut ON ut.url_id = u.id
+		JOIN tags t ON t.id = ut.tag_id
+		LEFT JOIN url_click_counts cc ON cc.url_id = u.id
+		WHERE t.name = $1
+		ORDER BY clicks DESC, u.alias`, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag links: %w", err)
+	}
+	defer rows.Close()
+
+	report := &domain.TagReport{Tag: tag}
+
+	for rows.Next() {
+		var link domain.TagLinkStats
+		if err := rows.Scan(&link.Alias, &link.OriginalURL, &link.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan tag link row: %w", err)
+		}
+		report.Links = append(report.Links, link)
+		report.TotalClicks += link.Clicks
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag links: %w", err)
+	}
+
+	if len(report.Links) == 0 {
+		return nil, fmt.Errorf("%w: no links for tag %s", repo.ErrNotFound, tag)
+	}
+
+	report.DailyStats, err = r.countClicksBy(ctx,
+		`SELECT to_char(c.clicked_at, 'YYYY-MM-DD'), COUNT(*)
+		FROM clicks c
+		JOIN url_tags ut ON ut.url_id = c.url_id
+		JOIN tags t ON t.id = ut.tag_id
+		WHERE t.name = $1
+		GROUP BY 1`, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag daily stats: %w", err)
+	}
+
+	report.ChannelStats, err = r.countClicksBy(ctx,
+		`SELECT c.channel, COUNT(*)
+		FROM clicks c
+		JOIN url_tags ut ON ut.url_id = c.url_id
+		JOIN tags t ON t.id = ut.tag_id
+		WHERE t.name = $1
+		GROUP BY c.channel`, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag channel stats: %w", err)
 	}
 
 	return report, nil
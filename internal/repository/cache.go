@@ -0,0 +1,11 @@
+package repository
+
+import "time"
+
+// CacheEntry is one value of a bulk cache write; a zero TTL keeps it
+// until deleted.
+type CacheEntry struct {
+	Key   string
+	Value string
+	TTL   time.Duration
+}
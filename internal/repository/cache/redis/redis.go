@@ -2,16 +2,24 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"url-shortener-wb/internal/config"
 
 	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
 
+	goredis "github.com/go-redis/redis/v8"
 	wbfredis "github.com/wb-go/wbf/redis"
 	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("url-shortener-wb/internal/repository/cache/redis")
+
 type RedisCache struct {
 	client  *wbfredis.Client
 	retries retry.Strategy
@@ -25,28 +33,105 @@ func NewRedisCache(cfg *config.Config, retries retry.Strategy) *RedisCache {
 	}
 }
 
-func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
+func (c *RedisCache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "RedisCache.Get", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+	))
+	defer func() {
+		if errors.Is(err, repo.ErrCacheMiss) {
+			span.End()
+			return
+		}
+		tracing.End(span, err)
+	}()
+
 	value, err := c.client.GetWithRetry(ctx, c.retries, key)
 	if err != nil {
 		if err.Error() == "redis: nil" {
+			span.SetAttributes(attribute.Bool("cache.hit", false))
 			return "", fmt.Errorf("%w: key %s not found", repo.ErrCacheMiss, key)
 		}
 		return "", fmt.Errorf("failed to get from cache: %w", err)
 	}
+	span.SetAttributes(attribute.Bool("cache.hit", true))
 	return value, nil
 }
 
-func (c *RedisCache) Set(ctx context.Context, key, value string) error {
-	err := c.client.SetWithRetry(ctx, c.retries, key, value)
+func (c *RedisCache) Set(ctx context.Context, key, value string, ttl time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "RedisCache.Set", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+		attribute.Int64("cache.ttl_ms", ttl.Milliseconds()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, c.retries, func() error {
+		return c.client.SetWithExpiration(ctx, key, value, ttl)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to set cache: %w", err)
 	}
 	return nil
 }
 
-func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
+// SetMany writes all entries in a single pipelined round trip.
+func (c *RedisCache) SetMany(ctx context.Context, entries []repo.CacheEntry) (err error) {
+	ctx, span := tracer.Start(ctx, "RedisCache.SetMany", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.Int("cache.keys", len(entries)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err = retry.DoContext(ctx, c.retries, func() error {
+		_, err := c.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for _, e := range entries {
+				pipe.Set(ctx, e.Key, e.Value, e.TTL)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set cache entries: %w", err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Delete(ctx context.Context, key string) (err error) {
+	ctx, span := tracer.Start(ctx, "RedisCache.Delete", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = c.client.DelWithRetry(ctx, c.retries, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete from cache: %w", err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Exists(ctx context.Context, key string) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "RedisCache.Exists", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	var count int64
-	err := retry.DoContext(ctx, c.retries, func() error {
+	err = retry.DoContext(ctx, c.retries, func() error {
 		var err error
 		count, err = c.client.Exists(ctx, key).Result()
 		if err != nil {
@@ -60,3 +145,31 @@ func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// incrScript increments a counter and gives it a TTL unless it already has
+// one, in a single step: a counter left without expiry would lock its key
+// out forever.
+var incrScript = goredis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if tonumber(ARGV[1]) > 0 and redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Incr atomically increments the counter stored at key and, when ttl is
+// set, starts its expiry on creation. It is not retried because a retried
+// increment could be applied twice.
+func (c *RedisCache) Incr(ctx context.Context, key string, ttl time.Duration) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "RedisCache.Incr", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	n, err := incrScript.Run(ctx, c.client, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w", err)
+	}
+	return n, nil
+}
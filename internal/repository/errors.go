@@ -6,4 +6,6 @@ var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
 	ErrCacheMiss     = errors.New("cache miss")
+	ErrLimitExceeded = errors.New("limit exceeded")
+	ErrInUse         = errors.New("resource in use")
 )
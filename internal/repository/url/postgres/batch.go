@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CreateBatch inserts the links in one transaction and reports which of
+// them were written. When atomic, a taken alias rolls back the whole batch
+// with repo.ErrAlreadyExists; otherwise links whose alias is taken are
+// skipped.
+func (r *URLRepository) CreateBatch(ctx context.Context, urls []*domain.URL, atomic bool) (_ []bool, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.CreateBatch", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int("batch.size", len(urls)),
+		attribute.Bool("batch.atomic", atomic),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	created := make([]bool, len(urls))
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			for i, url := range urls {
+				ok, err := insertURL(ctx, tx, url, !atomic)
+				if err != nil {
+					if isUniqueViolation(err) {
+						return fmt.Errorf("%w: alias %s already exists", repo.ErrAlreadyExists, url.Alias)
+					}
+					return err
+				}
+				created[i] = ok
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert url batch: %w", err)
+	}
+	return created, nil
+}
+
+// ExistingAliases returns which of the given aliases are already taken.
+func (r *URLRepository) ExistingAliases(ctx context.Context, aliases []string) (_ map[string]bool, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ExistingAliases", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int("batch.size", len(aliases)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	existing := make(map[string]bool)
+	if len(aliases) == 0 {
+		return existing, nil
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT alias FROM urls WHERE alias = ANY($1)`, pq.Array(aliases))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing aliases: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		existing[alias] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating existing aliases: %w", err)
+	}
+
+	return existing, nil
+}
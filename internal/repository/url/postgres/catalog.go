@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// catalogColumns are the url columns followed by the click total and tags,
+// as read by scanCatalogEntry. They are selected from catalogTables.
+const catalogColumns = urlColumns + `, cc.clicks,
+	ARRAY(SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+		WHERE ut.url_id = urls.id ORDER BY t.name)`
+
+// catalogTables joins every link to its click total, a row created with
+// the link.
+const catalogTables = `urls JOIN url_click_counts cc ON cc.url_id = urls.id`
+
+// extraScanner scans the url columns followed by additional ones.
+type extraScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func (s extraScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+func scanCatalogEntry(row rowScanner) (domain.CatalogEntry, error) {
+	var (
+		clicks int64
+		tags   pq.StringArray
+	)
+	url, err := scanURL(extraScanner{row: row, extra: []any{&clicks, &tags}})
+	if err != nil {
+		return domain.CatalogEntry{}, err
+	}
+	url.Tags = tags
+	return domain.CatalogEntry{URL: url, Clicks: clicks}, nil
+}
+
+// ListCatalog returns up to limit links with an ID above afterID in ID
+// order, with their tags and click totals.
+func (r *URLRepository) ListCatalog(ctx context.Context, afterID int64, limit int) (_ []domain.CatalogEntry, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ListCatalog", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("after_id", afterID),
+		attribute.Int("limit", limit),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT `+catalogColumns+`
+		FROM `+catalogTables+`
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.CatalogEntry
+	for rows.Next() {
+		entry, err := scanCatalogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan catalog row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating catalog: %w", err)
+	}
+
+	return entries, nil
+}
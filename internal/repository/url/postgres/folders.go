@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const folderColumns = `f.id, COALESCE(f.parent_id, 0), f.name, f.created_at,
+	(SELECT COUNT(*) FROM urls u WHERE u.folder_id = f.id)`
+
+func scanFolder(row rowScanner) (*domain.Folder, error) {
+	folder := &domain.Folder{}
+	err := row.Scan(&folder.ID, &folder.ParentID, &folder.Name, &folder.CreatedAt, &folder.Links)
+	if err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// ListFolders returns all folders with the number of links directly in each.
+func (r *URLRepository) ListFolders(ctx context.Context) (_ []*domain.Folder, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ListFolders", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT `+folderColumns+`
+		FROM folders f
+		ORDER BY f.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folders: %w", err)
+	}
+	defer rows.Close()
+
+	var folders []*domain.Folder
+	for rows.Next() {
+		folder, err := scanFolder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan folder row: %w", err)
+		}
+		folders = append(folders, folder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+func (r *URLRepository) GetFolder(ctx context.Context, id int64) (_ *domain.Folder, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetFolder", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("folder.id", id),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.retries,
+		`SELECT `+folderColumns+`
+		FROM folders f WHERE f.id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: folder %d not found", repo.ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to query folder: %w", err)
+	}
+
+	folder, err := scanFolder(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: folder %d not found", repo.ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to scan folder row: %w", err)
+	}
+
+	return folder, nil
+}
+
+func (r *URLRepository) CreateFolder(ctx context.Context, folder *domain.Folder) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.CreateFolder", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("folder.parent_id", folder.ParentID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = r.db.Master.QueryRowContext(ctx,
+		`INSERT INTO folders (parent_id, name) VALUES ($1, $2)
+		RETURNING id, created_at`,
+		nullID(folder.ParentID), folder.Name,
+	).Scan(&folder.ID, &folder.CreatedAt)
+	if err != nil {
+		return folderError(err, folder)
+	}
+	return nil
+}
+
+// UpdateFolder renames or moves a folder.
+func (r *URLRepository) UpdateFolder(ctx context.Context, folder *domain.Folder) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.UpdateFolder", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("folder.id", folder.ID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	res, err := r.db.ExecWithRetry(ctx, r.retries,
+		`UPDATE folders SET parent_id = $1, name = $2 WHERE id = $3`,
+		nullID(folder.ParentID), folder.Name, folder.ID)
+	if err != nil {
+		return folderError(err, folder)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: folder %d not found", repo.ErrNotFound, folder.ID)
+	}
+	return nil
+}
+
+// DeleteFolder removes a folder. A folder still holding links or
+// subfolders is not removed and repo.ErrInUse is returned.
+func (r *URLRepository) DeleteFolder(ctx context.Context, id int64) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.DeleteFolder", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("folder.id", id),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	res, err := r.db.ExecWithRetry(ctx, r.retries, `DELETE FROM folders WHERE id = $1`, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("%w: folder %d is not empty", repo.ErrInUse, id)
+		}
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: folder %d not found", repo.ErrNotFound, id)
+	}
+	return nil
+}
+
+// folderError maps a failed folder write: a taken name within the parent
+// and a parent removed in the meantime.
+func folderError(err error, folder *domain.Folder) error {
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: folder %s already exists in its parent", repo.ErrAlreadyExists, folder.Name)
+	}
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("%w: parent folder %d not found", repo.ErrNotFound, folder.ParentID)
+	}
+	return fmt.Errorf("failed to write folder: %w", err)
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusConditions mirror URL.Active, NotYetActive and Expired, with an
+// exhausted click limit counting as expired.
+var statusConditions = map[domain.LinkStatus]string{
+	domain.StatusActive: `NOT disabled
+		AND (active_from IS NULL OR active_from <= NOW())
+		AND (active_until IS NULL OR active_until > NOW())
+		AND (max_clicks = 0 OR clicks_used < max_clicks)`,
+	domain.StatusScheduled: `NOT disabled AND active_from > NOW()`,
+	domain.StatusExpired: `NOT disabled
+		AND (active_until <= NOW() OR (max_clicks > 0 AND clicks_used >= max_clicks))`,
+	domain.StatusDisabled: `disabled`,
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListLinks returns the links matching filter in its sort order, starting
+// after filter.After. Substring search is served by the trigram indexes
+// on alias and original_url.
+func (r *URLRepository) ListLinks(ctx context.Context, filter domain.LinkFilter) (_ []domain.CatalogEntry, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ListLinks", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("list.sort", string(filter.Sort)),
+		attribute.Int("limit", filter.Limit),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Owner != "" {
+		where = append(where, "owner = "+arg(filter.Owner))
+	}
+	if filter.Tag != "" {
+		where = append(where, `EXISTS (SELECT 1 FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = urls.id AND t.name = `+arg(filter.Tag)+`)`)
+	}
+	if filter.FolderID != 0 {
+		where = append(where, `folder_id IN (
+			WITH RECURSIVE tree AS (
+				SELECT id FROM folders WHERE id = `+arg(filter.FolderID)+`
+				UNION ALL
+				SELECT f.id FROM folders f JOIN tree ON f.parent_id = tree.id
+			)
+			SELECT id FROM tree)`)
+	}
+	if filter.Search != "" {
+		pattern := arg("%" + likeEscaper.Replace(filter.Search) + "%")
+		where = append(where, "(alias ILIKE "+pattern+" OR original_url ILIKE "+pattern+")")
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= "+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, "created_at < "+arg(filter.CreatedBefore))
+	}
+	if filter.Status != "" {
+		where = append(where, statusConditions[filter.Status])
+	}
+
+	// Sorting by clicks walks the index on the separate counter table.
+	column, idColumn := "created_at", "id"
+	if filter.Sort == domain.SortClicksAsc || filter.Sort == domain.SortClicksDesc {
+		column, idColumn = "cc.clicks", "cc.url_id"
+	}
+	op, dir := ">", "ASC"
+	if filter.Sort.Descending() {
+		op, dir = "<", "DESC"
+	}
+	if after := filter.After; after != nil {
+		var value any = after.CreatedAt
+		if column == "cc.clicks" {
+			value = after.Clicks
+		}
+		where = append(where, fmt.Sprintf("(%s, %s) %s (%s, %s)", column, idColumn, op, arg(value), arg(after.ID)))
+	}
+
+	query := `SELECT ` + catalogColumns + ` FROM ` + catalogTables
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, %s %s LIMIT %s`, column, dir, idColumn, dir, arg(filter.Limit))
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.CatalogEntry
+	for rows.Next() {
+		entry, err := scanCatalogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating links: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (r *URLRepository) GetLocales(ctx context.Context, urlID int64) (_ map[string]string, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetLocales", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT locale, target FROM url_locales WHERE url_id = $1`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url locales: %w", err)
+	}
+	defer rows.Close()
+
+	var locales map[string]string
+	for rows.Next() {
+		var locale, target string
+		if err := rows.Scan(&locale, &target); err != nil {
+			return nil, fmt.Errorf("failed to scan url locale row: %w", err)
+		}
+		if locales == nil {
+			locales = make(map[string]string)
+		}
+		locales[locale] = target
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating url locales: %w", err)
+	}
+
+	return locales, nil
+}
+
+// ReplaceLocales atomically swaps the locale map of a link.
+func (r *URLRepository) ReplaceLocales(ctx context.Context, urlID int64, locales map[string]string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ReplaceLocales", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+		attribute.Int("locales.count", len(locales)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM url_locales WHERE url_id = $1`, urlID); err != nil {
+				return fmt.Errorf("failed to delete url locales: %w", err)
+			}
+			return insertLocales(ctx, tx, urlID, locales)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace url locales: %w", err)
+	}
+	return nil
+}
+
+func insertLocales(ctx context.Context, tx *sql.Tx, urlID int64, locales map[string]string) error {
+	for locale, target := range locales {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO url_locales (url_id, locale, target) VALUES ($1, $2, $3)`,
+			urlID, locale, target,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert url locale: %w", err)
+		}
+	}
+	return nil
+}
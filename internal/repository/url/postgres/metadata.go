@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (r *URLRepository) GetMetadata(ctx context.Context, urlID int64) (_ *domain.Metadata, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetMetadata", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() {
+		if errors.Is(err, repo.ErrNotFound) {
+			span.End()
+			return
+		}
+		tracing.End(span, err)
+	}()
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.retries,
+		`SELECT title, description, favicon_url, image_url, fetch_error, fetched_at
+		FROM url_metadata WHERE url_id = $1`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url metadata: %w", err)
+	}
+
+	var meta domain.Metadata
+	err = row.Scan(&meta.Title, &meta.Description, &meta.FaviconURL, &meta.ImageURL, &meta.Error, &meta.FetchedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: no metadata for url %d", repo.ErrNotFound, urlID)
+		}
+		return nil, fmt.Errorf("failed to scan url metadata row: %w", err)
+	}
+	return &meta, nil
+}
+
+// SaveMetadata stores the latest fetch result of a link, replacing any
+// earlier one.
+func (r *URLRepository) SaveMetadata(ctx context.Context, urlID int64, meta *domain.Metadata) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.SaveMetadata", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = r.db.ExecWithRetry(ctx, r.retries,
+		`INSERT INTO url_metadata (url_id, title, description, favicon_url, image_url, fetch_error, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (url_id) DO UPDATE SET
+			title = EXCLUDED.title, description = EXCLUDED.description,
+			favicon_url = EXCLUDED.favicon_url, image_url = EXCLUDED.image_url,
+			fetch_error = EXCLUDED.fetch_error, fetched_at = EXCLUDED.fetched_at`,
+		urlID, meta.Title, meta.Description, meta.FaviconURL, meta.ImageURL, meta.Error, meta.FetchedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save url metadata: %w", err)
+	}
+	return nil
+}
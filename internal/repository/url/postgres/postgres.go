@@ -5,14 +5,71 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"url-shortener-wb/internal/domain"
 	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
 
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("url-shortener-wb/internal/repository/url/postgres")
+
+const urlColumns = `id, original_url, alias, redirect_type,
+	forward_query, forward_path, query_merge,
+	utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+	rotation, sticky_variants,
+	active_from, active_until,
+	max_clicks, clicks_used, fallback_url,
+	password_hash, require_signature,
+	disabled, target_down, show_preview,
+	og_title, og_description, og_image,
+	owner, COALESCE(folder_id, 0), created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanURL(row rowScanner) (*domain.URL, error) {
+	var (
+		url                     domain.URL
+		activeFrom, activeUntil sql.NullTime
+	)
+	err := row.Scan(
+		&url.ID, &url.OriginalURL, &url.Alias, &url.RedirectType,
+		&url.ForwardQuery, &url.ForwardPath, &url.QueryMerge,
+		&url.UTM.Source, &url.UTM.Medium, &url.UTM.Campaign, &url.UTM.Term, &url.UTM.Content,
+		&url.Split.Rotation, &url.Split.Sticky,
+		&activeFrom, &activeUntil,
+		&url.MaxClicks, &url.ClicksUsed, &url.FallbackURL,
+		&url.PasswordHash, &url.RequireSignature,
+		&url.Disabled, &url.TargetDown, &url.Preview,
+		&url.OpenGraph.Title, &url.OpenGraph.Description, &url.OpenGraph.ImageURL,
+		&url.Owner, &url.FolderID, &url.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	url.ActiveFrom = activeFrom.Time
+	url.ActiveUntil = activeUntil.Time
+	return &url, nil
+}
+
+// nullTime stores the zero time, an open window bound, as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// nullID stores the zero ID, such as the top-level folder, as NULL.
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
 type URLRepository struct {
 	db      *dbpg.DB
 	retries retry.Strategy
@@ -28,14 +85,21 @@ func NewURLRepository(
 	}
 }
 
-func (r *URLRepository) Create(ctx context.Context, url *domain.URL) error {
-	_, err := r.db.ExecWithRetry(ctx, r.retries,
-		`INSERT INTO urls (original_url, alias, created_at)
-		VALUES ($1, $2, $3) RETURNING id`,
-		url.OriginalURL, url.Alias, url.CreatedAt,
-	)
+func (r *URLRepository) Create(ctx context.Context, url *domain.URL) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.Create", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("url.alias", url.Alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			_, err := insertURL(ctx, tx, url, false)
+			return err
+		})
+	})
 	if err != nil {
-		if err.Error() == "pq: duplicate key value violates unique constraint \"urls_alias_key\"" {
+		if isUniqueViolation(err) {
 			return fmt.Errorf("%w: alias %s already exists", repo.ErrAlreadyExists, url.Alias)
 		}
 		return fmt.Errorf("failed to insert url: %w", err)
@@ -43,9 +107,78 @@ func (r *URLRepository) Create(ctx context.Context, url *domain.URL) error {
 	return nil
 }
 
-func (r *URLRepository) GetByAlias(ctx context.Context, alias string) (*domain.URL, error) {
+// insertURL writes the link with its rules, locales, variants, schedule and
+// tags.
+// With skipConflict a taken alias is not an error: nothing is written and
+// false is returned.
+func insertURL(ctx context.Context, tx *sql.Tx, url *domain.URL, skipConflict bool) (bool, error) {
+	query := `INSERT INTO urls (original_url, alias, redirect_type,
+			forward_query, forward_path, query_merge,
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+			rotation, sticky_variants,
+			active_from, active_until,
+			max_clicks, fallback_url,
+			password_hash, require_signature, show_preview,
+			og_title, og_description, og_image,
+			owner, folder_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+			$22, $23, $24, $25, $26)`
+	if skipConflict {
+		query += ` ON CONFLICT (alias) DO NOTHING`
+	}
+	err := tx.QueryRowContext(ctx, query+` RETURNING id`,
+		url.OriginalURL, url.Alias, url.RedirectType,
+		url.ForwardQuery, url.ForwardPath, url.QueryMerge,
+		url.UTM.Source, url.UTM.Medium, url.UTM.Campaign, url.UTM.Term, url.UTM.Content,
+		url.Split.Rotation, url.Split.Sticky,
+		nullTime(url.ActiveFrom), nullTime(url.ActiveUntil),
+		url.MaxClicks, url.FallbackURL,
+		url.PasswordHash, url.RequireSignature, url.Preview,
+		url.OpenGraph.Title, url.OpenGraph.Description, url.OpenGraph.ImageURL,
+		url.Owner, nullID(url.FolderID), url.CreatedAt,
+	).Scan(&url.ID)
+	if err != nil {
+		if skipConflict && errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO url_click_counts (url_id) VALUES ($1)`, url.ID); err != nil {
+		return false, fmt.Errorf("failed to insert click count: %w", err)
+	}
+	if err := insertRules(ctx, tx, url.ID, url.Rules); err != nil {
+		return false, err
+	}
+	if err := insertLocales(ctx, tx, url.ID, url.Locales); err != nil {
+		return false, err
+	}
+	if err := insertVariants(ctx, tx, url.ID, url.Split.Variants); err != nil {
+		return false, err
+	}
+	if err := insertSchedule(ctx, tx, url.ID, url.Schedule); err != nil {
+		return false, err
+	}
+	if err := insertTags(ctx, tx, url.ID, url.Tags); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (r *URLRepository) GetByAlias(ctx context.Context, alias string) (_ *domain.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetByAlias", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	row, err := r.db.QueryRowWithRetry(ctx, r.retries,
-		`SELECT id, original_url, alias, created_at
+		`SELECT `+urlColumns+`
 		FROM urls WHERE alias = $1 LIMIT 1`, alias)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -54,20 +187,121 @@ func (r *URLRepository) GetByAlias(ctx context.Context, alias string) (*domain.U
 		return nil, fmt.Errorf("failed to query url by alias: %w", err)
 	}
 
-	var url domain.URL
-	if err := row.Scan(&url.ID, &url.OriginalURL, &url.Alias, &url.CreatedAt); err != nil {
+	url, err := scanURL(row)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%w: alias %s not found", repo.ErrNotFound, alias)
 		}
 		return nil, fmt.Errorf("failed to scan url row: %w", err)
 	}
 
-	return &url, nil
+	return url, nil
+}
+
+func (r *URLRepository) Update(ctx context.Context, url *domain.URL) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.Update", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("url.alias", url.Alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	res, err := r.db.ExecWithRetry(ctx, r.retries,
+		`UPDATE urls SET redirect_type = $1, forward_query = $2,
+			forward_path = $3, query_merge = $4,
+			active_from = $5, active_until = $6,
+			max_clicks = $7, fallback_url = $8,
+			password_hash = $9, require_signature = $10,
+			disabled = $11, show_preview = $12,
+			og_title = $13, og_description = $14, og_image = $15,
+			folder_id = $16
+		WHERE alias = $17`,
+		url.RedirectType, url.ForwardQuery,
+		url.ForwardPath, url.QueryMerge,
+		nullTime(url.ActiveFrom), nullTime(url.ActiveUntil),
+		url.MaxClicks, url.FallbackURL,
+		url.PasswordHash, url.RequireSignature,
+		url.Disabled, url.Preview,
+		url.OpenGraph.Title, url.OpenGraph.Description, url.OpenGraph.ImageURL,
+		nullID(url.FolderID),
+		url.Alias,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: alias %s not found", repo.ErrNotFound, url.Alias)
+	}
+	return nil
+}
+
+// ConsumeClick atomically uses up one click of a click-limited link and
+// reports whether one was still available. It is not retried: a retry of
+// an update that did commit would burn a second click.
+func (r *URLRepository) ConsumeClick(ctx context.Context, urlID int64) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ConsumeClick", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	res, err := r.db.Master.ExecContext(ctx,
+		`UPDATE urls SET clicks_used = clicks_used + 1
+		WHERE id = $1 AND clicks_used < max_clicks`, urlID)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume click: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// HasClicksLeft reports whether a click-limited link can still be visited,
+// without using up a click. It reads the master so that a link exhausted a
+// moment ago is not reported as available.
+func (r *URLRepository) HasClicksLeft(ctx context.Context, urlID int64) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.HasClicksLeft", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var left bool
+	err = retry.DoContext(ctx, r.retries, func() error {
+		row := r.db.Master.QueryRowContext(ctx,
+			`SELECT clicks_used < max_clicks FROM urls WHERE id = $1`, urlID)
+
+		if err := row.Scan(&left); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("failed to scan clicks left: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check clicks left: %w", err)
+	}
+	return left, nil
 }
 
-func (r *URLRepository) ExistsByAlias(ctx context.Context, alias string) (bool, error) {
+func (r *URLRepository) ExistsByAlias(ctx context.Context, alias string) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ExistsByAlias", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	var exists bool
-	err := retry.DoContext(ctx, r.retries, func() error {
+	err = retry.DoContext(ctx, r.retries, func() error {
 		row := r.db.QueryRowContext(ctx,
 			`SELECT EXISTS(SELECT 1 FROM urls WHERE alias = $1)`, alias)
 
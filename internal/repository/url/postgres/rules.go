@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (r *URLRepository) GetRules(ctx context.Context, urlID int64) (_ []domain.Rule, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetRules", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT id, priority, os, device, country, target
+		FROM url_rules WHERE url_id = $1
+		ORDER BY priority, id`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.Rule
+	for rows.Next() {
+		var rule domain.Rule
+		if err := rows.Scan(&rule.ID, &rule.Priority, &rule.OS, &rule.Device, &rule.Country, &rule.Target); err != nil {
+			return nil, fmt.Errorf("failed to scan url rule row: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating url rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ReplaceRules atomically swaps the rule set of a link.
+func (r *URLRepository) ReplaceRules(ctx context.Context, urlID int64, rules []domain.Rule) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ReplaceRules", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+		attribute.Int("rules.count", len(rules)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM url_rules WHERE url_id = $1`, urlID); err != nil {
+				return fmt.Errorf("failed to delete url rules: %w", err)
+			}
+			return insertRules(ctx, tx, urlID, rules)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace url rules: %w", err)
+	}
+	return nil
+}
+
+func insertRules(ctx context.Context, tx *sql.Tx, urlID int64, rules []domain.Rule) error {
+	for i := range rules {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO url_rules (url_id, priority, os, device, country, target)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			urlID, rules[i].Priority, rules[i].OS, rules[i].Device, rules[i].Country, rules[i].Target,
+		).Scan(&rules[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert url rule: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *URLRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
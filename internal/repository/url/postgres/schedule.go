@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (r *URLRepository) GetSchedule(ctx context.Context, urlID int64) (_ []domain.ScheduleEntry, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetSchedule", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT id, starts_at, target
+		FROM url_schedule WHERE url_id = $1
+		ORDER BY starts_at`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url schedule: %w", err)
+	}
+	defer rows.Close()
+
+	var schedule []domain.ScheduleEntry
+	for rows.Next() {
+		var entry domain.ScheduleEntry
+		if err := rows.Scan(&entry.ID, &entry.StartsAt, &entry.Target); err != nil {
+			return nil, fmt.Errorf("failed to scan url schedule row: %w", err)
+		}
+		schedule = append(schedule, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating url schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// ReplaceSchedule atomically swaps the destination schedule of a link.
+func (r *URLRepository) ReplaceSchedule(ctx context.Context, urlID int64, schedule []domain.ScheduleEntry) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ReplaceSchedule", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+		attribute.Int("schedule.count", len(schedule)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM url_schedule WHERE url_id = $1`, urlID); err != nil {
+				return fmt.Errorf("failed to delete url schedule: %w", err)
+			}
+			return insertSchedule(ctx, tx, urlID, schedule)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace url schedule: %w", err)
+	}
+	return nil
+}
+
+func insertSchedule(ctx context.Context, tx *sql.Tx, urlID int64, schedule []domain.ScheduleEntry) error {
+	for i := range schedule {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO url_schedule (url_id, starts_at, target)
+			VALUES ($1, $2, $3) RETURNING id`,
+			urlID, schedule[i].StartsAt, schedule[i].Target,
+		).Scan(&schedule[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert url schedule entry: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (r *URLRepository) GetTags(ctx context.Context, urlID int64) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetTags", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT t.name FROM url_tags ut
+		JOIN tags t ON t.id = ut.tag_id
+		WHERE ut.url_id = $1
+		ORDER BY t.name`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan url tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating url tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// ListTags returns all tags by name, with the number of links carrying each.
+func (r *URLRepository) ListTags(ctx context.Context) (_ []domain.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ListTags", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT t.id, t.name, COUNT(ut.url_id)
+		FROM tags t
+		LEFT JOIN url_tags ut ON ut.tag_id = t.id
+		GROUP BY t.id
+		ORDER BY t.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []domain.Tag
+	for rows.Next() {
+		var tag domain.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Links); err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (r *URLRepository) CreateTag(ctx context.Context, name string) (_ *domain.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.CreateTag", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("tag.name", name),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	tag := &domain.Tag{Name: name}
+	err = r.db.Master.QueryRowContext(ctx,
+		`INSERT INTO tags (name) VALUES ($1) RETURNING id`, name,
+	).Scan(&tag.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: tag %s", repo.ErrAlreadyExists, name)
+		}
+		return nil, fmt.Errorf("failed to insert tag: %w", err)
+	}
+	return tag, nil
+}
+
+func (r *URLRepository) RenameTag(ctx context.Context, name, newName string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.RenameTag", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("tag.name", name),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	res, err := r.db.ExecWithRetry(ctx, r.retries,
+		`UPDATE tags SET name = $2 WHERE name = $1`, name, newName)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: tag %s", repo.ErrAlreadyExists, newName)
+		}
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: tag %s not found", repo.ErrNotFound, name)
+	}
+	return nil
+}
+
+// DeleteTag removes a tag; the links carrying it keep their other tags.
+func (r *URLRepository) DeleteTag(ctx context.Context, name string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.DeleteTag", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("tag.name", name),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	res, err := r.db.ExecWithRetry(ctx, r.retries, `DELETE FROM tags WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: tag %s not found", repo.ErrNotFound, name)
+	}
+	return nil
+}
+
+// ReplaceTags atomically swaps the tags of a link.
+func (r *URLRepository) ReplaceTags(ctx context.Context, urlID int64, tags []string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ReplaceTags", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+		attribute.Int("tags.count", len(tags)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM url_tags WHERE url_id = $1`, urlID); err != nil {
+				return fmt.Errorf("failed to delete url tags: %w", err)
+			}
+			return insertTags(ctx, tx, urlID, tags)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace url tags: %w", err)
+	}
+	return nil
+}
+
+// AssignTags adds and removes tags on many links at once and returns the
+// aliases that were found. Nothing is changed if a link would end up with
+// more than maxPerLink tags.
+func (r *URLRepository) AssignTags(ctx context.Context, aliases, add, remove []string, maxPerLink int) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.AssignTags", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int("aliases.count", len(aliases)),
+		attribute.Int("tags.added", len(add)),
+		attribute.Int("tags.removed", len(remove)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var found []string
+	err = retry.DoContext(ctx, r.retries, func() error {
+		found = nil
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			ids, err := lockAliases(ctx, tx, aliases)
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+
+			urlIDs := make([]int64, 0, len(ids))
+			for alias, id := range ids {
+				found = append(found, alias)
+				urlIDs = append(urlIDs, id)
+			}
+
+			if len(remove) > 0 {
+				_, err := tx.ExecContext(ctx,
+					`DELETE FROM url_tags
+					WHERE url_id = ANY($1) AND tag_id IN (SELECT id FROM tags WHERE name = ANY($2))`,
+					pq.Array(urlIDs), pq.Array(remove))
+				if err != nil {
+					return fmt.Errorf("failed to remove url tags: %w", err)
+				}
+			}
+
+			for _, tag := range add {
+				var tagID int64
+				err := tx.QueryRowContext(ctx,
+					`INSERT INTO tags (name) VALUES ($1)
+					ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+					RETURNING id`, tag,
+				).Scan(&tagID)
+				if err != nil {
+					return fmt.Errorf("failed to upsert tag: %w", err)
+				}
+
+				_, err = tx.ExecContext(ctx,
+					`INSERT INTO url_tags (url_id, tag_id)
+					SELECT unnest($1::bigint[]), $2
+					ON CONFLICT DO NOTHING`, pq.Array(urlIDs), tagID)
+				if err != nil {
+					return fmt.Errorf("failed to insert url tags: %w", err)
+				}
+			}
+
+			var exceeded bool
+			err = tx.QueryRowContext(ctx,
+				`SELECT EXISTS(
+					SELECT 1 FROM url_tags WHERE url_id = ANY($1)
+					GROUP BY url_id HAVING COUNT(*) > $2
+				)`, pq.Array(urlIDs), maxPerLink,
+			).Scan(&exceeded)
+			if err != nil {
+				return fmt.Errorf("failed to count url tags: %w", err)
+			}
+			if exceeded {
+				return fmt.Errorf("%w: at most %d tags per link", repo.ErrLimitExceeded, maxPerLink)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign tags: %w", err)
+	}
+	return found, nil
+}
+
+// lockAliases maps the existing aliases to their link IDs, locking the
+// links against concurrent tag changes.
+func lockAliases(ctx context.Context, tx *sql.Tx, aliases []string) (map[string]int64, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT alias, id FROM urls WHERE alias = ANY($1) ORDER BY id FOR UPDATE`, pq.Array(aliases))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int64)
+	for rows.Next() {
+		var (
+			alias string
+			id    int64
+		)
+		if err := rows.Scan(&alias, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan link row: %w", err)
+		}
+		ids[alias] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating links: %w", err)
+	}
+	return ids, nil
+}
+
+// insertTags attaches tags to a link, creating the ones not seen before.
+func insertTags(ctx context.Context, tx *sql.Tx, urlID int64, tags []string) error {
+	for _, tag := range tags {
+		var tagID int64
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO tags (name) VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`, tag,
+		).Scan(&tagID)
+		if err != nil {
+			return fmt.Errorf("failed to upsert tag: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO url_tags (url_id, tag_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, urlID, tagID)
+		if err != nil {
+			return fmt.Errorf("failed to insert url tag: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ListTargetsToCheck returns enabled links with a fallback URL, least
+// recently checked first.
+func (r *URLRepository) ListTargetsToCheck(ctx context.Context, limit int) (_ []*domain.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ListTargetsToCheck", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int("limit", limit),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT `+urlColumns+`
+		FROM urls
+		WHERE fallback_url <> '' AND NOT disabled
+		ORDER BY target_checked_at NULLS FIRST
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links to check: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*domain.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan url row: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating links to check: %w", err)
+	}
+
+	return urls, nil
+}
+
+// RecordTargetCheck stores the outcome of a probe. The target is flagged
+// down after threshold consecutive failures and up again after one success.
+// changed reports whether the flag flipped. It runs on the master and is
+// not retried: a retry of an update that did commit would count a failure
+// twice.
+func (r *URLRepository) RecordTargetCheck(ctx context.Context, urlID int64, ok bool, threshold int) (down, changed bool, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.RecordTargetCheck", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+		attribute.Bool("target.ok", ok),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var wasDown bool
+	err = r.db.Master.QueryRowContext(ctx,
+		`UPDATE urls u SET
+			target_failures = CASE WHEN $2 THEN 0 ELSE u.target_failures + 1 END,
+			target_down = CASE WHEN $2 THEN FALSE ELSE u.target_failures + 1 >= $3 END,
+			target_checked_at = NOW()
+		FROM (SELECT id, target_down FROM urls WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING u.target_down, old.target_down`,
+		urlID, ok, threshold,
+	).Scan(&down, &wasDown)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to record target check: %w", err)
+	}
+	return down, down != wasDown, nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetVariants loads the variants of a link; rotation settings are part of
+// the urls row and are scanned by GetByAlias.
+func (r *URLRepository) GetVariants(ctx context.Context, urlID int64) (_ []domain.Variant, err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.GetVariants", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryWithRetry(ctx, r.retries,
+		`SELECT id, name, target, weight
+		FROM url_variants WHERE url_id = $1
+		ORDER BY id`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url variants: %w", err)
+	}
+	defer rows.Close()
+
+	var variants []domain.Variant
+	for rows.Next() {
+		var v domain.Variant
+		if err := rows.Scan(&v.ID, &v.Name, &v.Target, &v.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan url variant row: %w", err)
+		}
+		variants = append(variants, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating url variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+// ReplaceSplit atomically swaps the rotation settings and variants of a link.
+func (r *URLRepository) ReplaceSplit(ctx context.Context, urlID int64, split *domain.Split) (err error) {
+	ctx, span := tracer.Start(ctx, "URLRepository.ReplaceSplit", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.Int64("url.id", urlID),
+		attribute.Int("variants.count", len(split.Variants)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = retry.DoContext(ctx, r.retries, func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx,
+				`UPDATE urls SET rotation = $1, sticky_variants = $2 WHERE id = $3`,
+				split.Rotation, split.Sticky, urlID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update url rotation: %w", err)
+			}
+			if n, err := res.RowsAffected(); err == nil && n == 0 {
+				return fmt.Errorf("%w: url %d not found", repo.ErrNotFound, urlID)
+			}
+
+			if _, err := tx.ExecContext(ctx, `DELETE FROM url_variants WHERE url_id = $1`, urlID); err != nil {
+				return fmt.Errorf("failed to delete url variants: %w", err)
+			}
+			return insertVariants(ctx, tx, urlID, split.Variants)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace url split: %w", err)
+	}
+	return nil
+}
+
+func insertVariants(ctx context.Context, tx *sql.Tx, urlID int64, variants []domain.Variant) error {
+	for i := range variants {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO url_variants (url_id, name, target, weight)
+			VALUES ($1, $2, $3, $4) RETURNING id`,
+			urlID, variants[i].Name, variants[i].Target, variants[i].Weight,
+		).Scan(&variants[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert url variant: %w", err)
+		}
+	}
+	return nil
+}
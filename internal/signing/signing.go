@@ -0,0 +1,110 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query parameters carried by a signed link.
+const (
+	ParamExpires   = "exp"
+	ParamKeyID     = "kid"
+	ParamSignature = "sig"
+)
+
+var (
+	ErrDisabled = errors.New("link signing is not configured")
+	ErrInvalid  = errors.New("invalid link signature")
+	ErrExpired  = errors.New("link signature has expired")
+)
+
+// Keyring signs links with the active key and verifies them with any key
+// it holds. Keys are rotated by adding a new key, making it active, and
+// removing the old one once its signatures should no longer be honoured.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+func NewKeyring(keys map[string]string, active string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte, len(keys)), active: active}
+	for id, secret := range keys {
+		if id == "" || secret == "" {
+			return nil, fmt.Errorf("signing key %q: empty key id or secret", id)
+		}
+		k.keys[id] = []byte(secret)
+	}
+
+	if k.active == "" && len(k.keys) == 1 {
+		for id := range k.keys {
+			k.active = id
+		}
+	}
+	if len(k.keys) > 0 {
+		if _, ok := k.keys[k.active]; !ok {
+			return nil, fmt.Errorf("active signing key %q is not configured", k.active)
+		}
+	}
+	return k, nil
+}
+
+// Sign returns the query parameters that make a link to alias valid until
+// expires.
+func (k *Keyring) Sign(alias string, expires time.Time) (url.Values, error) {
+	if k.active == "" {
+		return nil, ErrDisabled
+	}
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return url.Values{
+		ParamExpires:   {exp},
+		ParamKeyID:     {k.active},
+		ParamSignature: {mac(k.keys[k.active], alias, exp, k.active)},
+	}, nil
+}
+
+// Verify checks the signature parameters in query for alias at now and
+// returns when the signature expires.
+func (k *Keyring) Verify(alias string, query url.Values, now time.Time) (time.Time, error) {
+	exp, kid, sig := query.Get(ParamExpires), query.Get(ParamKeyID), query.Get(ParamSignature)
+	if exp == "" || sig == "" {
+		return time.Time{}, fmt.Errorf("%w: missing parameters", ErrInvalid)
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: unknown key %q", ErrInvalid, kid)
+	}
+	if !hmac.Equal([]byte(sig), []byte(mac(key, alias, exp, kid))) {
+		return time.Time{}, ErrInvalid
+	}
+
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed expiry", ErrInvalid)
+	}
+	expires := time.Unix(unix, 0)
+	if !now.Before(expires) {
+		return time.Time{}, ErrExpired
+	}
+	return expires, nil
+}
+
+// Strip removes the signature parameters so they are not forwarded to the
+// destination.
+func Strip(query url.Values) {
+	query.Del(ParamExpires)
+	query.Del(ParamKeyID)
+	query.Del(ParamSignature)
+}
+
+func mac(key []byte, alias, exp, kid string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(alias + "\n" + exp + "\n" + kid))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
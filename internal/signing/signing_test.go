@@ -0,0 +1,117 @@
+package signing
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newKeyring(t *testing.T, keys map[string]string, active string) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(keys, active)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return k
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+
+	old := newKeyring(t, map[string]string{"k1": "old-secret"}, "k1")
+	rotated := newKeyring(t, map[string]string{"k1": "old-secret", "k2": "new-secret"}, "k2")
+	retired := newKeyring(t, map[string]string{"k2": "new-secret"}, "k2")
+
+	signed, err := old.Sign("abc123", expires)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	with := func(key, value string) url.Values {
+		q := url.Values{}
+		for k, v := range signed {
+			q[k] = append([]string(nil), v...)
+		}
+		q.Set(key, value)
+		return q
+	}
+	without := func(key string) url.Values {
+		q := with(key, "")
+		q.Del(key)
+		return q
+	}
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		alias   string
+		query   url.Values
+		now     time.Time
+		wantErr error
+	}{
+		{name: "valid", keyring: old, alias: "abc123", query: signed, now: now},
+		{name: "old key kept after rotation", keyring: rotated, alias: "abc123", query: signed, now: now},
+		{name: "retired key", keyring: retired, alias: "abc123", query: signed, now: now, wantErr: ErrInvalid},
+		{name: "unknown kid", keyring: rotated, alias: "abc123", query: with(ParamKeyID, "k9"), now: now, wantErr: ErrInvalid},
+		{name: "kid swapped to another key", keyring: rotated, alias: "abc123", query: with(ParamKeyID, "k2"), now: now, wantErr: ErrInvalid},
+		{name: "tampered signature", keyring: old, alias: "abc123", query: with(ParamSignature, "AAAA"), now: now, wantErr: ErrInvalid},
+		{name: "extended expiry", keyring: old, alias: "abc123", query: with(ParamExpires, "9999999999"), now: now, wantErr: ErrInvalid},
+		{name: "malformed expiry", keyring: old, alias: "abc123", query: with(ParamExpires, "soon"), now: now, wantErr: ErrInvalid},
+		{name: "other alias", keyring: old, alias: "xyz789", query: signed, now: now, wantErr: ErrInvalid},
+		{name: "missing signature", keyring: old, alias: "abc123", query: without(ParamSignature), now: now, wantErr: ErrInvalid},
+		{name: "missing expiry", keyring: old, alias: "abc123", query: without(ParamExpires), now: now, wantErr: ErrInvalid},
+		{name: "expired", keyring: old, alias: "abc123", query: signed, now: expires, wantErr: ErrExpired},
+		{name: "disabled", keyring: newKeyring(t, nil, ""), alias: "abc123", query: signed, now: now, wantErr: ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.Verify(tt.alias, tt.query, tt.now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !got.Equal(expires) {
+				t.Errorf("Verify() = %v, want %v", got, expires)
+			}
+		})
+	}
+}
+
+func TestSignUsesActiveKey(t *testing.T) {
+	k := newKeyring(t, map[string]string{"k1": "old-secret", "k2": "new-secret"}, "k2")
+
+	q, err := k.Sign("abc123", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if got := q.Get(ParamKeyID); got != "k2" {
+		t.Errorf("kid = %q, want k2", got)
+	}
+}
+
+func TestSignDisabled(t *testing.T) {
+	if _, err := newKeyring(t, nil, "").Sign("abc123", time.Now()); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Sign() error = %v, want %v", err, ErrDisabled)
+	}
+}
+
+func TestNewKeyringRejectsUnknownActiveKey(t *testing.T) {
+	if _, err := NewKeyring(map[string]string{"k1": "secret"}, "k2"); err == nil {
+		t.Error("NewKeyring() error = nil, want an error")
+	}
+}
+
+func TestStrip(t *testing.T) {
+	q := url.Values{ParamExpires: {"1"}, ParamKeyID: {"k1"}, ParamSignature: {"s"}, "utm_source": {"mail"}}
+	Strip(q)
+	if len(q) != 1 || q.Get("utm_source") != "mail" {
+		t.Errorf("Strip() left %v, want only utm_source", q)
+	}
+}
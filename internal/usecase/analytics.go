@@ -4,47 +4,254 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/zlog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+type clickEvent struct {
+	alias     string
+	click     domain.Click
+	link      trace.Link
+	requestID string
+}
+
 type analyticsUsecase struct {
 	analyticsRepo AnalyticsRepository
 	urlRepo       URLRepository
+	logger        *zlog.Zerolog
+
+	clicks  chan clickEvent
+	workers int
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+
+	// counts holds click totals per link not yet added to the database.
+	countsMu      sync.Mutex
+	counts        map[int64]int64
+	flushInterval time.Duration
+	stopFlush     chan struct{}
+	flushWg       sync.WaitGroup
+	stopOnce      sync.Once
 }
 
-func NewAnalyticsUsecase(analyticsRepo AnalyticsRepository, urlRepo URLRepository) *analyticsUsecase {
+func NewAnalyticsUsecase(
+	analyticsRepo AnalyticsRepository,
+	urlRepo URLRepository,
+	logger *zlog.Zerolog,
+	queueSize int,
+	workers int,
+	flushInterval time.Duration,
+) *analyticsUsecase {
+	if workers <= 0 {
+		workers = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
 	return &analyticsUsecase{
 		analyticsRepo: analyticsRepo,
 		urlRepo:       urlRepo,
+		logger:        logger,
+		clicks:        make(chan clickEvent, queueSize),
+		workers:       workers,
+		counts:        make(map[int64]int64),
+		flushInterval: flushInterval,
+		stopFlush:     make(chan struct{}),
 	}
 }
 
-func (au *analyticsUsecase) RecordClick(ctx context.Context, alias, userAgent, ip string) error {
-	url, err := au.urlRepo.GetByAlias(ctx, alias)
-	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+func (au *analyticsUsecase) Start() {
+	for i := 0; i < au.workers; i++ {
+		au.wg.Add(1)
+		go au.worker()
+	}
+	au.flushWg.Add(1)
+	go au.flushLoop()
+}
+
+// Stop closes the click queue, waits for the workers to drain it and adds
+// the remaining click totals.
+func (au *analyticsUsecase) Stop(ctx context.Context) error {
+	au.mu.Lock()
+	if !au.closed {
+		au.closed = true
+		close(au.clicks)
+	}
+	au.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		au.wg.Wait()
+		au.stopOnce.Do(func() { close(au.stopFlush) })
+		au.flushWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("click queue not drained: %w", ctx.Err())
+	}
+}
+
+// flushLoop periodically adds the click totals gathered by the workers, so
+// a click costs an insert into clicks rather than an update of its link.
+func (au *analyticsUsecase) flushLoop() {
+	defer au.flushWg.Done()
+
+	ticker := time.NewTicker(au.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			au.flushCounts()
+		case <-au.stopFlush:
+			au.flushCounts()
+			return
+		}
+	}
+}
+
+func (au *analyticsUsecase) flushCounts() {
+	au.countsMu.Lock()
+	counts := au.counts
+	au.counts = make(map[int64]int64)
+	au.countsMu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+
+	if err := au.analyticsRepo.AddClickCounts(context.Background(), counts); err != nil {
+		au.logger.Warn().Err(err).Int("links", len(counts)).Msg("failed to add click counts, retrying later")
+		au.countsMu.Lock()
+		for id, n := range counts {
+			au.counts[id] += n
 		}
-		return fmt.Errorf("failed to get url by alias: %w", err)
+		au.countsMu.Unlock()
+	}
+}
+
+func (au *analyticsUsecase) worker() {
+	defer au.wg.Done()
+	for ev := range au.clicks {
+		metrics.ClickQueueDepth.Dec()
+		if err := au.processClick(ev); err != nil {
+			metrics.ClicksRecorded.WithLabelValues("error").Inc()
+			au.logger.Warn().Err(err).Str("alias", ev.alias).Str("request_id", ev.requestID).Msg("failed to record click")
+			continue
+		}
+		metrics.ClicksRecorded.WithLabelValues("ok").Inc()
+	}
+}
+
+// processClick records a queued click in its own trace, linked to the
+// request that produced it.
+func (au *analyticsUsecase) processClick(ev clickEvent) (err error) {
+	ctx, span := tracer.Start(context.Background(), "analyticsUsecase.processClick",
+		trace.WithNewRoot(),
+		trace.WithLinks(ev.link),
+		trace.WithAttributes(attribute.String("url.alias", ev.alias)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return au.recordClick(ctx, ev)
+}
+
+// TrackClick queues a click for asynchronous recording. Clicks are dropped
+// rather than blocking the redirect when the queue is full. When click.URLID
+// is zero the link is resolved by alias before recording.
+func (au *analyticsUsecase) TrackClick(ctx context.Context, alias string, click domain.Click) {
+	au.mu.RLock()
+	defer au.mu.RUnlock()
+
+	if au.closed {
+		metrics.ClicksDropped.Inc()
+		return
 	}
 
-	click := &domain.Click{
-		URLID:     url.ID,
-		UserAgent: userAgent,
-		IPAddress: ip,
-		ClickedAt: time.Now(),
+	if click.ClickedAt.IsZero() {
+		click.ClickedAt = time.Now()
 	}
 
-	if err := au.analyticsRepo.RecordClick(ctx, click); err != nil {
+	ev := clickEvent{
+		alias:     alias,
+		click:     click,
+		link:      trace.LinkFromContext(ctx),
+		requestID: logging.RequestID(ctx),
+	}
+
+	select {
+	case au.clicks <- ev:
+		metrics.ClickQueueDepth.Inc()
+	default:
+		metrics.ClicksDropped.Inc()
+		logging.FromContext(ctx, au.logger).Warn().Str("alias", alias).Msg("click queue is full, dropping click")
+	}
+}
+
+func (au *analyticsUsecase) RecordClick(ctx context.Context, alias, userAgent, ip string) (err error) {
+	ctx, span := tracer.Start(ctx, "analyticsUsecase.RecordClick", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return au.recordClick(ctx, clickEvent{
+		alias: alias,
+		click: domain.Click{
+			UserAgent: userAgent,
+			IPAddress: ip,
+			ClickedAt: time.Now(),
+		},
+	})
+}
+
+func (au *analyticsUsecase) recordClick(ctx context.Context, ev clickEvent) error {
+	click := ev.click
+	if click.URLID == 0 {
+		url, err := au.urlRepo.GetByAlias(ctx, ev.alias)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%w: url not found for alias %s", ErrNotFound, ev.alias)
+			}
+			return fmt.Errorf("failed to get url by alias: %w", err)
+		}
+		click.URLID = url.ID
+	}
+	if click.Channel == "" {
+		click.Channel = domain.ChannelLink
+	}
+
+	if err := au.analyticsRepo.RecordClick(ctx, &click); err != nil {
 		return fmt.Errorf("failed to record click: %w", err)
 	}
 
+	au.countsMu.Lock()
+	au.counts[click.URLID]++
+	au.countsMu.Unlock()
+
 	return nil
 }
 
-func (au *analyticsUsecase) GetAnalytics(ctx context.Context, alias string) (*domain.AnalyticsReport, error) {
+func (au *analyticsUsecase) GetAnalytics(ctx context.Context, alias string) (_ *domain.AnalyticsReport, err error) {
+	ctx, span := tracer.Start(ctx, "analyticsUsecase.GetAnalytics", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	if alias == "" {
 		return nil, fmt.Errorf("%w: empty alias", ErrInvalidAlias)
 	}
@@ -59,3 +266,48 @@ func (au *analyticsUsecase) GetAnalytics(ctx context.Context, alias string) (*do
 
 	return report, nil
 }
+
+func (au *analyticsUsecase) GetCampaignStats(ctx context.Context, campaign string) (_ *domain.CampaignReport, err error) {
+	ctx, span := tracer.Start(ctx, "analyticsUsecase.GetCampaignStats", trace.WithAttributes(
+		attribute.String("utm.campaign", campaign),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	campaign = strings.TrimSpace(campaign)
+	if campaign == "" || len(campaign) > maxUTMValueLength {
+		return nil, fmt.Errorf("%w: campaign must be 1-%d characters", ErrInvalidCampaign, maxUTMValueLength)
+	}
+
+	report, err := au.analyticsRepo.GetCampaignStats(ctx, campaign)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: campaign %s not found", ErrNotFound, campaign)
+		}
+		return nil, fmt.Errorf("failed to get campaign stats: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetTagStats sums up the clicks of all links carrying tag.
+func (au *analyticsUsecase) GetTagStats(ctx context.Context, tag string) (_ *domain.TagReport, err error) {
+	ctx, span := tracer.Start(ctx, "analyticsUsecase.GetTagStats", trace.WithAttributes(
+		attribute.String("tag.name", tag),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	tag, err = normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := au.analyticsRepo.GetTagStats(ctx, tag)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: no links tagged %s", ErrNotFound, tag)
+		}
+		return nil, fmt.Errorf("failed to get tag stats: %w", err)
+	}
+
+	return report, nil
+}
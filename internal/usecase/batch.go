@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MaxBatchSize caps the number of links created by one batch request.
+	MaxBatchSize = 5000
+
+	// aliasAttempts bounds how often colliding generated aliases are redrawn.
+	aliasAttempts = 5
+)
+
+// batch is a set of links being created together. urls[i] is nil once
+// item i has failed, and taken maps every alias claimed so far to its item.
+type batch struct {
+	results []domain.BatchResult
+	urls    []*domain.URL
+	taken   map[string]int
+}
+
+func (b *batch) fail(i int, err error) {
+	b.results[i].Err = err
+	b.urls[i] = nil
+}
+
+func (b *batch) failed() int {
+	n := 0
+	for _, res := range b.results {
+		if res.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// CreateShortURLs creates many links at once. Every item is validated on its
+// own and the result slice reports each item's alias or error in input
+// order. When atomic, any failed item rejects the whole batch with
+// ErrBatchRejected and nothing is written; otherwise the valid items are
+// created and the rest are reported.
+func (u *urlUsecase) CreateShortURLs(ctx context.Context, inputs []domain.CreateURLInput, atomic bool) (_ []domain.BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.CreateShortURLs", trace.WithAttributes(
+		attribute.Int("batch.size", len(inputs)),
+		attribute.Bool("batch.atomic", atomic),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: no items", ErrInvalidBatch)
+	}
+	if len(inputs) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d items are allowed, got %d", ErrInvalidBatch, MaxBatchSize, len(inputs))
+	}
+
+	b, err := u.prepareBatch(ctx, inputs, validateCustomAlias)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.generateAliases(ctx, b.urls, b.taken); err != nil {
+		return nil, err
+	}
+
+	failed := b.failed()
+	span.SetAttributes(attribute.Int("batch.failed", failed))
+	if atomic && failed > 0 {
+		return b.results, fmt.Errorf("%w: %d of %d items are invalid", ErrBatchRejected, failed, len(inputs))
+	}
+
+	if err := u.writeBatch(ctx, b, b.urls, 0, atomic); err != nil {
+		return nil, err
+	}
+	return b.results, nil
+}
+
+// prepareBatch validates every item and checks the requested aliases, both
+// against each other and against the database in a single query.
+func (u *urlUsecase) prepareBatch(ctx context.Context, inputs []domain.CreateURLInput, validateAlias func(string) error) (*batch, error) {
+	b := &batch{
+		results: make([]domain.BatchResult, len(inputs)),
+		urls:    make([]*domain.URL, len(inputs)),
+		taken:   make(map[string]int),
+	}
+	// Links are usually filed into a few folders, each checked once.
+	folders := make(map[int64]error)
+	for i, in := range inputs {
+		if err := validateAlias(in.CustomAlias); err != nil {
+			b.fail(i, err)
+			continue
+		}
+		url, err := newURL(in)
+		if err != nil {
+			b.fail(i, err)
+			continue
+		}
+		folderErr, checked := folders[url.FolderID]
+		if !checked {
+			folderErr = u.checkFolder(ctx, url.FolderID)
+			if folderErr != nil && !errors.Is(folderErr, ErrInvalidFolder) {
+				return nil, folderErr
+			}
+			folders[url.FolderID] = folderErr
+		}
+		if folderErr != nil {
+			b.fail(i, folderErr)
+			continue
+		}
+		if url.Alias != "" {
+			if j, ok := b.taken[url.Alias]; ok {
+				b.fail(i, fmt.Errorf("%w: alias %s is also requested by item %d", ErrAliasExists, url.Alias, j))
+				continue
+			}
+			b.taken[url.Alias] = i
+		}
+		b.urls[i] = url
+	}
+
+	custom := make([]string, 0, len(b.taken))
+	for alias := range b.taken {
+		custom = append(custom, alias)
+	}
+	existing, err := u.urlRepo.ExistingAliases(ctx, custom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check alias existence: %w", err)
+	}
+	for alias := range existing {
+		b.fail(b.taken[alias], fmt.Errorf("%w: alias %s", ErrAliasExists, alias))
+	}
+	return b, nil
+}
+
+// writeBatch stores urls, a window of b.urls starting at item offset, in one
+// transaction, then caches the created links and queues their metadata.
+func (u *urlUsecase) writeBatch(ctx context.Context, b *batch, urls []*domain.URL, offset int, atomic bool) error {
+	var (
+		pending []*domain.URL
+		indexes []int
+	)
+	for k, url := range urls {
+		if url != nil {
+			pending = append(pending, url)
+			indexes = append(indexes, offset+k)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	written, err := u.urlRepo.CreateBatch(ctx, pending, atomic)
+	if err != nil {
+		return fmt.Errorf("failed to create urls: %w", err)
+	}
+
+	created := make([]*domain.URL, 0, len(pending))
+	for k, ok := range written {
+		i := indexes[k]
+		if !ok {
+			// Taken by a concurrent create after the alias check.
+			b.fail(i, fmt.Errorf("%w: alias %s", ErrAliasExists, pending[k].Alias))
+			continue
+		}
+		b.results[i].Alias = pending[k].Alias
+		created = append(created, pending[k])
+	}
+
+	u.cacheURLs(ctx, created)
+	for _, url := range created {
+		u.preview.Queue.FetchMetadata(ctx, url)
+	}
+	return nil
+}
+
+// generateAliases draws random aliases for the links without a custom one,
+// checking the whole set against the database in one query per round and
+// redrawing only those that collide.
+func (u *urlUsecase) generateAliases(ctx context.Context, urls []*domain.URL, taken map[string]int) error {
+	var pending []int
+	for i, url := range urls {
+		if url != nil && url.Alias == "" {
+			pending = append(pending, i)
+		}
+	}
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt == aliasAttempts {
+			return fmt.Errorf("failed to generate %d unique aliases", len(pending))
+		}
+
+		var (
+			aliases []string
+			retry   []int
+		)
+		for _, i := range pending {
+			alias, err := randomAlias()
+			if err != nil {
+				return err
+			}
+			if _, ok := taken[alias]; ok {
+				retry = append(retry, i)
+				continue
+			}
+			taken[alias] = i
+			urls[i].Alias = alias
+			aliases = append(aliases, alias)
+		}
+
+		existing, err := u.urlRepo.ExistingAliases(ctx, aliases)
+		if err != nil {
+			return fmt.Errorf("failed to check alias existence: %w", err)
+		}
+		for alias := range existing {
+			i := taken[alias]
+			delete(taken, alias)
+			urls[i].Alias = ""
+			retry = append(retry, i)
+		}
+		pending = retry
+	}
+	return nil
+}
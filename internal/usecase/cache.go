@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	repo "url-shortener-wb/internal/repository"
+)
+
+// cachedURL is the cache representation of a link. Everything needed to
+// serve a redirect is stored so that the hot path never touches Postgres.
+type cachedURL struct {
+	ID           int64               `json:"id"`
+	OriginalURL  string              `json:"original_url"`
+	RedirectType domain.RedirectType `json:"redirect_type"`
+	ForwardQuery bool                `json:"forward_query,omitempty"`
+	ForwardPath  bool                `json:"forward_path,omitempty"`
+	QueryMerge   domain.QueryMerge   `json:"query_merge,omitempty"`
+	Rules        []cachedRule        `json:"rules,omitempty"`
+	Locales      map[string]string   `json:"locales,omitempty"`
+	Rotation     domain.Rotation     `json:"rotation,omitempty"`
+	Sticky       bool                `json:"sticky,omitempty"`
+	Variants     []cachedVariant     `json:"variants,omitempty"`
+	ActiveFrom   time.Time           `json:"active_from,omitzero"`
+	ActiveUntil  time.Time           `json:"active_until,omitzero"`
+	Schedule     []cachedSchedule    `json:"schedule,omitempty"`
+	MaxClicks    int                 `json:"max_clicks,omitempty"`
+	FallbackURL  string              `json:"fallback_url,omitempty"`
+	PasswordHash string              `json:"password_hash,omitempty"`
+	RequireSig   bool                `json:"require_signature,omitempty"`
+	Disabled     bool                `json:"disabled,omitempty"`
+	TargetDown   bool                `json:"target_down,omitempty"`
+	Preview      bool                `json:"preview,omitempty"`
+	OpenGraph    domain.OpenGraph    `json:"open_graph,omitzero"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+type cachedRule struct {
+	ID       int64  `json:"id"`
+	Priority int    `json:"priority,omitempty"`
+	OS       string `json:"os,omitempty"`
+	Device   string `json:"device,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Target   string `json:"target"`
+}
+
+type cachedVariant struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+type cachedSchedule struct {
+	ID       int64     `json:"id"`
+	StartsAt time.Time `json:"starts_at"`
+	Target   string    `json:"target"`
+}
+
+func encodeCachedURL(url *domain.URL) (string, error) {
+	rules := make([]cachedRule, len(url.Rules))
+	for i, rule := range url.Rules {
+		rules[i] = cachedRule(rule)
+	}
+	variants := make([]cachedVariant, len(url.Split.Variants))
+	for i, v := range url.Split.Variants {
+		variants[i] = cachedVariant(v)
+	}
+	schedule := make([]cachedSchedule, len(url.Schedule))
+	for i, entry := range url.Schedule {
+		schedule[i] = cachedSchedule(entry)
+	}
+
+	data, err := json.Marshal(cachedURL{
+		ID:           url.ID,
+		OriginalURL:  url.OriginalURL,
+		RedirectType: url.RedirectType,
+		ForwardQuery: url.ForwardQuery,
+		ForwardPath:  url.ForwardPath,
+		QueryMerge:   url.QueryMerge,
+		Rules:        rules,
+		Locales:      url.Locales,
+		Rotation:     url.Split.Rotation,
+		Sticky:       url.Split.Sticky,
+		Variants:     variants,
+		ActiveFrom:   url.ActiveFrom,
+		ActiveUntil:  url.ActiveUntil,
+		Schedule:     schedule,
+		MaxClicks:    url.MaxClicks,
+		FallbackURL:  url.FallbackURL,
+		PasswordHash: url.PasswordHash,
+		RequireSig:   url.RequireSignature,
+		Disabled:     url.Disabled,
+		TargetDown:   url.TargetDown,
+		Preview:      url.Preview,
+		OpenGraph:    url.OpenGraph,
+		CreatedAt:    url.CreatedAt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cached url: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeCachedURL(alias, value string) (*domain.URL, error) {
+	var c cachedURL
+	if err := json.Unmarshal([]byte(value), &c); err != nil {
+		return nil, fmt.Errorf("failed to decode cached url: %w", err)
+	}
+
+	var rules []domain.Rule
+	for _, rule := range c.Rules {
+		// Entries cached before the country column became VARCHAR carry
+		// a blank-padded empty country.
+		rule.Country = strings.TrimSpace(rule.Country)
+		rules = append(rules, domain.Rule(rule))
+	}
+	var variants []domain.Variant
+	for _, v := range c.Variants {
+		variants = append(variants, domain.Variant(v))
+	}
+	var schedule []domain.ScheduleEntry
+	for _, entry := range c.Schedule {
+		schedule = append(schedule, domain.ScheduleEntry(entry))
+	}
+
+	return &domain.URL{
+		ID:           c.ID,
+		OriginalURL:  c.OriginalURL,
+		Alias:        alias,
+		RedirectType: c.RedirectType,
+		ForwardQuery: c.ForwardQuery,
+		ForwardPath:  c.ForwardPath,
+		QueryMerge:   c.QueryMerge,
+		Rules:        rules,
+		Locales:      c.Locales,
+		Split: domain.Split{
+			Rotation: c.Rotation,
+			Sticky:   c.Sticky,
+			Variants: variants,
+		},
+		ActiveFrom:       c.ActiveFrom,
+		ActiveUntil:      c.ActiveUntil,
+		Schedule:         schedule,
+		MaxClicks:        c.MaxClicks,
+		FallbackURL:      c.FallbackURL,
+		PasswordHash:     c.PasswordHash,
+		RequireSignature: c.RequireSig,
+		Disabled:         c.Disabled,
+		TargetDown:       c.TargetDown,
+		Preview:          c.Preview,
+		OpenGraph:        c.OpenGraph,
+		CreatedAt:        c.CreatedAt,
+	}, nil
+}
+
+// cacheURL stores the link until its next window or schedule boundary, so
+// that a switch is never delayed by a stale entry.
+func (u *urlUsecase) cacheURL(ctx context.Context, url *domain.URL) {
+	entry, err := cacheEntry(url)
+	if err == nil {
+		err = u.cache.Set(ctx, entry.Key, entry.Value, entry.TTL)
+	}
+	if err != nil {
+		logging.FromContext(ctx, u.logger).Warn().Err(err).Str("alias", url.Alias).Msg("failed to cache URL")
+	}
+}
+
+// cacheURLs stores many links in one round trip, as cacheURL does for one.
+func (u *urlUsecase) cacheURLs(ctx context.Context, urls []*domain.URL) {
+	entries := make([]repo.CacheEntry, 0, len(urls))
+	for _, url := range urls {
+		entry, err := cacheEntry(url)
+		if err != nil {
+			logging.FromContext(ctx, u.logger).Warn().Err(err).Str("alias", url.Alias).Msg("failed to cache URL")
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := u.cache.SetMany(ctx, entries); err != nil {
+		logging.FromContext(ctx, u.logger).Warn().Err(err).Int("count", len(entries)).Msg("failed to cache URLs")
+	}
+}
+
+func cacheEntry(url *domain.URL) (repo.CacheEntry, error) {
+	var ttl time.Duration
+	if next := url.NextBoundary(time.Now()); !next.IsZero() {
+		ttl = time.Until(next)
+	}
+
+	value, err := encodeCachedURL(url)
+	if err != nil {
+		return repo.CacheEntry{}, err
+	}
+	return repo.CacheEntry{Key: url.Alias, Value: value, TTL: ttl}, nil
+}
+
+// invalidateURL drops the cached link after an edit; the next lookup
+// reloads the complete link, including its rules, locales, variants and schedule, from the database.
+func (u *urlUsecase) invalidateURL(ctx context.Context, alias string) {
+	if err := u.cache.Delete(ctx, alias); err != nil {
+		logging.FromContext(ctx, u.logger).Warn().Err(err).Str("alias", alias).Msg("failed to invalidate cached URL")
+	}
+}
+
+// cachedURL returns false on a miss and on entries written in an older
+// format, which are then refreshed from the database.
+func (u *urlUsecase) cachedURL(ctx context.Context, alias string) (*domain.URL, bool) {
+	value, err := u.cache.Get(ctx, alias)
+	if err != nil {
+		return nil, false
+	}
+	url, err := decodeCachedURL(alias, value)
+	if err != nil {
+		logging.FromContext(ctx, u.logger).Debug().Err(err).Str("alias", alias).Msg("stale cache entry")
+		return nil, false
+	}
+	return url, true
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MaxImportSize caps the number of links in one import.
+	MaxImportSize = 100000
+
+	exportPageSize = 1000
+)
+
+// ImportURLs creates links migrated from another service. Custom aliases
+// may use the wider imported format and must be free; items without one
+// get a generated alias. Valid items are written in transactions of
+// MaxBatchSize links and failed ones are reported without blocking the
+// rest. With dryRun nothing is written and the results only tell which
+// items would fail.
+func (u *urlUsecase) ImportURLs(ctx context.Context, inputs []domain.CreateURLInput, dryRun bool) (_ []domain.BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ImportURLs", trace.WithAttributes(
+		attribute.Int("batch.size", len(inputs)),
+		attribute.Bool("import.dry_run", dryRun),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if len(inputs) > MaxImportSize {
+		return nil, fmt.Errorf("%w: at most %d links can be imported at once, got %d", ErrInvalidBatch, MaxImportSize, len(inputs))
+	}
+
+	b, err := u.prepareBatch(ctx, inputs, validateImportedAlias)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("batch.failed", b.failed()))
+
+	if dryRun {
+		for i, url := range b.urls {
+			if url != nil {
+				b.results[i].Alias = url.Alias
+			}
+		}
+		return b.results, nil
+	}
+
+	if err := u.generateAliases(ctx, b.urls, b.taken); err != nil {
+		return nil, err
+	}
+	for from := 0; from < len(b.urls); from += MaxBatchSize {
+		to := min(from+MaxBatchSize, len(b.urls))
+		if err := u.writeBatch(ctx, b, b.urls[from:to], from, false); err != nil {
+			return nil, err
+		}
+	}
+	return b.results, nil
+}
+
+// ExportURLs passes every link, with its tags and click total, to fn in
+// creation order, reading the catalog a page at a time.
+func (u *urlUsecase) ExportURLs(ctx context.Context, fn func(domain.CatalogEntry) error) (err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ExportURLs")
+	defer func() { tracing.End(span, err) }()
+
+	var afterID int64
+	for {
+		entries, err := u.urlRepo.ListCatalog(ctx, afterID, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list catalog: %w", err)
+		}
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(entries) < exportPageSize {
+			return nil
+		}
+		afterID = entries[len(entries)-1].URL.ID
+	}
+}
@@ -2,22 +2,83 @@ package usecase
 
 import (
 	"context"
+	"net/url"
+	"time"
+
 	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
 )
 
 type URLRepository interface {
 	Create(ctx context.Context, url *domain.URL) error
+	CreateBatch(ctx context.Context, urls []*domain.URL, atomic bool) ([]bool, error)
+	ExistingAliases(ctx context.Context, aliases []string) (map[string]bool, error)
+	ListCatalog(ctx context.Context, afterID int64, limit int) ([]domain.CatalogEntry, error)
+	ListLinks(ctx context.Context, filter domain.LinkFilter) ([]domain.CatalogEntry, error)
 	GetByAlias(ctx context.Context, alias string) (*domain.URL, error)
+	Update(ctx context.Context, url *domain.URL) error
+	GetRules(ctx context.Context, urlID int64) ([]domain.Rule, error)
+	ReplaceRules(ctx context.Context, urlID int64, rules []domain.Rule) error
+	GetLocales(ctx context.Context, urlID int64) (map[string]string, error)
+	ReplaceLocales(ctx context.Context, urlID int64, locales map[string]string) error
+	GetVariants(ctx context.Context, urlID int64) ([]domain.Variant, error)
+	ReplaceSplit(ctx context.Context, urlID int64, split *domain.Split) error
+	GetSchedule(ctx context.Context, urlID int64) ([]domain.ScheduleEntry, error)
+	ReplaceSchedule(ctx context.Context, urlID int64, schedule []domain.ScheduleEntry) error
+	GetTags(ctx context.Context, urlID int64) ([]string, error)
+	ReplaceTags(ctx context.Context, urlID int64, tags []string) error
+	AssignTags(ctx context.Context, aliases, add, remove []string, maxPerLink int) ([]string, error)
+	ListTags(ctx context.Context) ([]domain.Tag, error)
+	CreateTag(ctx context.Context, name string) (*domain.Tag, error)
+	RenameTag(ctx context.Context, name, newName string) error
+	DeleteTag(ctx context.Context, name string) error
+	ListFolders(ctx context.Context) ([]*domain.Folder, error)
+	GetFolder(ctx context.Context, id int64) (*domain.Folder, error)
+	CreateFolder(ctx context.Context, folder *domain.Folder) error
+	UpdateFolder(ctx context.Context, folder *domain.Folder) error
+	DeleteFolder(ctx context.Context, id int64) error
 	ExistsByAlias(ctx context.Context, alias string) (bool, error)
+	ConsumeClick(ctx context.Context, urlID int64) (bool, error)
+	HasClicksLeft(ctx context.Context, urlID int64) (bool, error)
+	GetMetadata(ctx context.Context, urlID int64) (*domain.Metadata, error)
+	SaveMetadata(ctx context.Context, urlID int64, meta *domain.Metadata) error
+	ListTargetsToCheck(ctx context.Context, limit int) ([]*domain.URL, error)
+	RecordTargetCheck(ctx context.Context, urlID int64, ok bool, threshold int) (down, changed bool, err error)
 }
 
 type AnalyticsRepository interface {
 	RecordClick(ctx context.Context, click *domain.Click) error
+	AddClickCounts(ctx context.Context, counts map[int64]int64) error
 	GetAnalytics(ctx context.Context, alias string) (*domain.AnalyticsReport, error)
+	GetCampaignStats(ctx context.Context, campaign string) (*domain.CampaignReport, error)
+	GetTagStats(ctx context.Context, tag string) (*domain.TagReport, error)
+}
+
+type LinkSigner interface {
+	Sign(alias string, expires time.Time) (url.Values, error)
+	Verify(alias string, query url.Values, now time.Time) (time.Time, error)
+}
+
+type PageFetcher interface {
+	Fetch(ctx context.Context, target string) (*domain.Metadata, error)
+}
+
+type MetadataQueue interface {
+	FetchMetadata(ctx context.Context, url *domain.URL)
+}
+
+type GeoLocator interface {
+	Country(ip string) string
 }
 
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
-	Set(ctx context.Context, key, value string) error
+	// Set stores value under key; a zero ttl keeps it until deleted.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	SetMany(ctx context.Context, entries []repo.CacheEntry) error
 	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// Incr increments the counter at key; a non-zero ttl is applied when
+	// the counter is created.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
 }
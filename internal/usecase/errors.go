@@ -1,10 +1,53 @@
 package usecase
 
-import "errors"
+import (
+	"errors"
+
+	repo "url-shortener-wb/internal/repository"
+)
 
 var (
-	ErrNotFound     = errors.New("url not found")
-	ErrAliasExists  = errors.New("alias already exists")
-	ErrInvalidURL   = errors.New("invalid url format")
-	ErrInvalidAlias = errors.New("invalid alias format")
+	// Repository sentinels are reused so that errors.Is matches errors
+	// returned from the storage layer.
+	ErrNotFound    = repo.ErrNotFound
+	ErrAliasExists = repo.ErrAlreadyExists
+
+	ErrInvalidURL          = errors.New("invalid url format")
+	ErrInvalidAlias        = errors.New("invalid alias format")
+	ErrInvalidRedirectType = errors.New("invalid redirect type")
+	ErrInvalidQueryMerge   = errors.New("invalid query merge mode")
+	ErrInvalidUTM          = errors.New("invalid utm parameters")
+	ErrInvalidCampaign     = errors.New("invalid campaign")
+	ErrInvalidRule         = errors.New("invalid redirect rule")
+	ErrInvalidLocale       = errors.New("invalid locale")
+	ErrInvalidVariant      = errors.New("invalid split variant")
+	ErrInvalidSchedule     = errors.New("invalid schedule")
+	ErrInvalidClickLimit   = errors.New("invalid click limit")
+	ErrInvalidPassword     = errors.New("invalid password")
+	ErrInvalidSignatureTTL = errors.New("invalid signed link ttl")
+	ErrInvalidOpenGraph    = errors.New("invalid open graph fields")
+	ErrInvalidTag          = errors.New("invalid tag")
+	ErrInvalidOwner        = errors.New("invalid owner")
+	ErrInvalidFilter       = errors.New("invalid link filter")
+	ErrInvalidBatch        = errors.New("invalid batch")
+	ErrInvalidFolder       = errors.New("invalid folder")
+
+	ErrBatchRejected = errors.New("batch rejected")
+
+	ErrTagExists      = errors.New("tag already exists")
+	ErrFolderExists   = errors.New("folder already exists")
+	ErrFolderNotEmpty = errors.New("folder is not empty")
+
+	ErrLinkNotActive = errors.New("link is not active yet")
+	ErrLinkExpired   = errors.New("link has expired")
+	ErrLinkExhausted = errors.New("link has reached its click limit")
+	ErrLinkDisabled  = errors.New("link is disabled")
+
+	ErrPasswordRequired = errors.New("link is password protected")
+	ErrWrongPassword    = errors.New("wrong password")
+	ErrTooManyAttempts  = errors.New("too many password attempts")
+
+	ErrInvalidSignature = errors.New("invalid link signature")
+	ErrSignatureExpired = errors.New("signed link has expired")
+	ErrSigningDisabled  = errors.New("link signing is not configured")
 )
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxFolderNameLength = 100
+
+func normalizeFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxFolderNameLength {
+		return "", fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidFolder, maxFolderNameLength)
+	}
+	// The slash separates the names in a folder path.
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("%w: name must not contain '/'", ErrInvalidFolder)
+	}
+	return name, nil
+}
+
+// folderTree indexes all folders by ID and fills in their paths.
+type folderTree map[int64]*domain.Folder
+
+func newFolderTree(folders []*domain.Folder) folderTree {
+	tree := make(folderTree, len(folders))
+	for _, f := range folders {
+		tree[f.ID] = f
+	}
+	for _, f := range folders {
+		tree.path(f)
+	}
+	return tree
+}
+
+func (t folderTree) path(f *domain.Folder) string {
+	if f.Path == "" {
+		f.Path = f.Name
+		if parent, ok := t[f.ParentID]; ok {
+			f.Path = t.path(parent) + "/" + f.Name
+		}
+	}
+	return f.Path
+}
+
+// contains reports whether folder id is ancestor or one of its subfolders.
+// The walk is bounded in case concurrent moves did close a cycle.
+func (t folderTree) contains(ancestor, id int64) bool {
+	f, ok := t[id]
+	for range len(t) {
+		if !ok {
+			return false
+		}
+		if f.ID == ancestor {
+			return true
+		}
+		f, ok = t[f.ParentID]
+	}
+	return true
+}
+
+func (u *urlUsecase) loadFolderTree(ctx context.Context) (folderTree, []*domain.Folder, error) {
+	folders, err := u.urlRepo.ListFolders(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return newFolderTree(folders), folders, nil
+}
+
+// ListFolders returns all folders with their paths, ordered by path.
+func (u *urlUsecase) ListFolders(ctx context.Context) (_ []*domain.Folder, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ListFolders")
+	defer func() { tracing.End(span, err) }()
+
+	_, folders, err := u.loadFolderTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(folders, func(a, b *domain.Folder) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return folders, nil
+}
+
+func (u *urlUsecase) GetFolder(ctx context.Context, id int64) (_ *domain.Folder, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetFolder", trace.WithAttributes(
+		attribute.Int64("folder.id", id),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	tree, _, err := u.loadFolderTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folder, ok := tree[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: folder %d not found", ErrNotFound, id)
+	}
+	return folder, nil
+}
+
+func (u *urlUsecase) CreateFolder(ctx context.Context, parentID int64, name string) (_ *domain.Folder, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.CreateFolder", trace.WithAttributes(
+		attribute.Int64("folder.parent_id", parentID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if name, err = normalizeFolderName(name); err != nil {
+		return nil, err
+	}
+
+	tree, _, err := u.loadFolderTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.checkParent(parentID); err != nil {
+		return nil, err
+	}
+
+	folder := &domain.Folder{ParentID: parentID, Name: name}
+	if err := u.urlRepo.CreateFolder(ctx, folder); err != nil {
+		return nil, folderError(err, folder)
+	}
+	tree[folder.ID] = folder
+	tree.path(folder)
+	return folder, nil
+}
+
+// UpdateFolder renames a folder or moves it, with its links and
+// subfolders, under another parent.
+func (u *urlUsecase) UpdateFolder(ctx context.Context, id int64, in domain.UpdateFolderInput) (_ *domain.Folder, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.UpdateFolder", trace.WithAttributes(
+		attribute.Int64("folder.id", id),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	tree, _, err := u.loadFolderTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := tree[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: folder %d not found", ErrNotFound, id)
+	}
+	folder := *current
+
+	if in.Name != nil {
+		if folder.Name, err = normalizeFolderName(*in.Name); err != nil {
+			return nil, err
+		}
+	}
+	if in.ParentID != nil {
+		if err := tree.checkParent(*in.ParentID); err != nil {
+			return nil, err
+		}
+		if tree.contains(id, *in.ParentID) {
+			return nil, fmt.Errorf("%w: a folder cannot be moved into itself or its subfolders", ErrInvalidFolder)
+		}
+		folder.ParentID = *in.ParentID
+	}
+
+	if err := u.urlRepo.UpdateFolder(ctx, &folder); err != nil {
+		return nil, folderError(err, &folder)
+	}
+
+	*current = folder
+	current.Path = ""
+	tree.path(current)
+	return current, nil
+}
+
+// DeleteFolder removes an empty folder.
+func (u *urlUsecase) DeleteFolder(ctx context.Context, id int64) (err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.DeleteFolder", trace.WithAttributes(
+		attribute.Int64("folder.id", id),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := u.urlRepo.DeleteFolder(ctx, id); err != nil {
+		if errors.Is(err, repo.ErrInUse) {
+			return fmt.Errorf("%w: move or delete its links and subfolders first", ErrFolderNotEmpty)
+		}
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: folder %d not found", ErrNotFound, id)
+		}
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+	return nil
+}
+
+func (t folderTree) checkParent(parentID int64) error {
+	if parentID < 0 {
+		return fmt.Errorf("%w: parent must be a folder id", ErrInvalidFolder)
+	}
+	if _, ok := t[parentID]; parentID != 0 && !ok {
+		return fmt.Errorf("%w: parent folder %d not found", ErrInvalidFolder, parentID)
+	}
+	return nil
+}
+
+func folderError(err error, folder *domain.Folder) error {
+	if errors.Is(err, repo.ErrAlreadyExists) {
+		return fmt.Errorf("%w: %q in this parent", ErrFolderExists, folder.Name)
+	}
+	if errors.Is(err, ErrNotFound) {
+		// The folder or its parent was removed in the meantime.
+		return fmt.Errorf("%w: %v", ErrInvalidFolder, err)
+	}
+	return fmt.Errorf("failed to save folder: %w", err)
+}
+
+// checkFolder verifies that a link may be put into folder id; 0 is the top
+// level.
+func (u *urlUsecase) checkFolder(ctx context.Context, id int64) error {
+	if id < 0 {
+		return fmt.Errorf("%w: folder must be a folder id", ErrInvalidFolder)
+	}
+	if id == 0 {
+		return nil
+	}
+	if _, err := u.urlRepo.GetFolder(ctx, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: folder %d not found", ErrInvalidFolder, id)
+		}
+		return fmt.Errorf("failed to get folder: %w", err)
+	}
+	return nil
+}
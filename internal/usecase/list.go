@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+
+	maxSearchLength = 200
+)
+
+// ListURLs returns one page of the links matching filter. cursor is the
+// NextCursor of the previous page, empty for the first one; it is only
+// valid with the sort order it was issued for.
+func (u *urlUsecase) ListURLs(ctx context.Context, filter domain.LinkFilter, cursor string) (_ *domain.LinkPage, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ListURLs", trace.WithAttributes(
+		attribute.String("list.sort", string(filter.Sort)),
+		attribute.Bool("list.continued", cursor != ""),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	filter, err = normalizeLinkFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		if filter.After, err = decodeLinkCursor(cursor, filter.Sort); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra link tells whether another page follows.
+	limit := filter.Limit
+	filter.Limit++
+	links, err := u.urlRepo.ListLinks(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	page := &domain.LinkPage{Links: links}
+	if len(links) > limit {
+		page.Links = links[:limit]
+		last := page.Links[limit-1]
+		page.NextCursor, err = encodeLinkCursor(domain.LinkCursor{
+			Sort:      filter.Sort,
+			CreatedAt: last.URL.CreatedAt,
+			Clicks:    last.Clicks,
+			ID:        last.URL.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	span.SetAttributes(attribute.Int("list.count", len(page.Links)))
+	return page, nil
+}
+
+func normalizeLinkFilter(f domain.LinkFilter) (domain.LinkFilter, error) {
+	f.Owner = strings.TrimSpace(f.Owner)
+	f.Tag = strings.ToLower(strings.TrimSpace(f.Tag))
+	f.Search = strings.TrimSpace(f.Search)
+
+	if utf8.RuneCountInString(f.Search) > maxSearchLength {
+		return f, fmt.Errorf("%w: search must be at most %d characters", ErrInvalidFilter, maxSearchLength)
+	}
+	if f.FolderID < 0 {
+		return f, fmt.Errorf("%w: folder must be a folder id", ErrInvalidFilter)
+	}
+	if f.Status != "" && !f.Status.Valid() {
+		return f, fmt.Errorf("%w: status %q, expected one of active, scheduled, expired, disabled", ErrInvalidFilter, f.Status)
+	}
+	if f.Sort == "" {
+		f.Sort = domain.DefaultLinkSort
+	}
+	if !f.Sort.Valid() {
+		return f, fmt.Errorf("%w: sort %q, expected one of created_at, -created_at, clicks, -clicks", ErrInvalidFilter, f.Sort)
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit < 1 || f.Limit > MaxListLimit {
+		return f, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxListLimit)
+	}
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && !f.CreatedBefore.After(f.CreatedAfter) {
+		return f, fmt.Errorf("%w: created_before must be after created_after", ErrInvalidFilter)
+	}
+	return f, nil
+}
+
+func encodeLinkCursor(c domain.LinkCursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeLinkCursor(s string, sort domain.LinkSort) (*domain.LinkCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	var c domain.LinkCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidFilter, c.Sort)
+	}
+	return &c, nil
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxLocalesPerLink = 50
+
+var localeRegex = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage parses an Accept-Language header (RFC 9110) into
+// language ranges ordered by descending quality. Ranges with q=0 and
+// malformed entries are dropped; equal weights keep header order.
+func parseAcceptLanguage(header string) []languageRange {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				q = 0
+				break
+			}
+			q = parsed
+		}
+		if q == 0 {
+			continue
+		}
+
+		ranges = append(ranges, languageRange{tag: tag, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// negotiateLocale picks the configured locale that best satisfies the
+// Accept-Language header. Each range is tried as is and then with subtags
+// stripped ("de-ch" → "de"); "*" falls through to the default destination.
+func negotiateLocale(locales map[string]string, header string) string {
+	if len(locales) == 0 || header == "" {
+		return ""
+	}
+
+	for _, r := range parseAcceptLanguage(header) {
+		for tag := r.tag; tag != "" && tag != "*"; {
+			if _, ok := locales[tag]; ok {
+				return tag
+			}
+			i := strings.LastIndex(tag, "-")
+			if i == -1 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+	return ""
+}
+
+func normalizeLocales(locales map[string]string) map[string]string {
+	if len(locales) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(locales))
+	for tag, target := range locales {
+		tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+		out[tag] = strings.TrimSpace(target)
+	}
+	return out
+}
+
+func validateLocales(locales map[string]string) error {
+	if len(locales) > maxLocalesPerLink {
+		return fmt.Errorf("%w: at most %d locales per link", ErrInvalidLocale, maxLocalesPerLink)
+	}
+	for tag, target := range locales {
+		if !localeRegex.MatchString(tag) {
+			return fmt.Errorf("%w: %q is not a language tag", ErrInvalidLocale, tag)
+		}
+		if err := validateURL(target); err != nil {
+			return fmt.Errorf("%w: locale %s target: %v", ErrInvalidLocale, tag, err)
+		}
+	}
+	return nil
+}
+
+func (u *urlUsecase) GetLocales(ctx context.Context, alias string) (_ map[string]string, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetLocales", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := u.lookupURL(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	return url.Locales, nil
+}
+
+func (u *urlUsecase) ReplaceLocales(ctx context.Context, alias string, locales map[string]string) (_ map[string]string, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ReplaceLocales", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+		attribute.Int("locales.count", len(locales)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	locales = normalizeLocales(locales)
+	if err := validateLocales(locales); err != nil {
+		return nil, err
+	}
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	if err := u.urlRepo.ReplaceLocales(ctx, url.ID, locales); err != nil {
+		return nil, fmt.Errorf("failed to replace locales: %w", err)
+	}
+
+	u.invalidateURL(ctx, alias)
+
+	return locales, nil
+}
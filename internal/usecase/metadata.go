@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/zlog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type metadataJob struct {
+	urlID     int64
+	alias     string
+	target    string
+	link      trace.Link
+	requestID string
+}
+
+// metadataUsecase fetches the destination metadata of new links in the
+// background, so that creating a link never waits on a remote site.
+type metadataUsecase struct {
+	urlRepo URLRepository
+	fetcher PageFetcher
+	timeout time.Duration
+	logger  *zlog.Zerolog
+
+	jobs    chan metadataJob
+	workers int
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+}
+
+func NewMetadataUsecase(
+	urlRepo URLRepository,
+	fetcher PageFetcher,
+	timeout time.Duration,
+	logger *zlog.Zerolog,
+	queueSize int,
+	workers int,
+) *metadataUsecase {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &metadataUsecase{
+		urlRepo: urlRepo,
+		fetcher: fetcher,
+		timeout: timeout,
+		logger:  logger,
+		jobs:    make(chan metadataJob, queueSize),
+		workers: workers,
+	}
+}
+
+func (mu *metadataUsecase) Start() {
+	for i := 0; i < mu.workers; i++ {
+		mu.wg.Add(1)
+		go mu.worker()
+	}
+}
+
+// Stop closes the fetch queue and waits for the workers to drain it.
+func (mu *metadataUsecase) Stop(ctx context.Context) error {
+	mu.mu.Lock()
+	if !mu.closed {
+		mu.closed = true
+		close(mu.jobs)
+	}
+	mu.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		mu.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("metadata queue not drained: %w", ctx.Err())
+	}
+}
+
+// FetchMetadata queues a fetch of the link's destination. Fetches are
+// dropped rather than blocking when the queue is full; the preview then
+// falls back to fetching on demand.
+func (mu *metadataUsecase) FetchMetadata(ctx context.Context, url *domain.URL) {
+	mu.mu.RLock()
+	defer mu.mu.RUnlock()
+
+	if mu.closed {
+		metrics.MetadataFetches.WithLabelValues("dropped").Inc()
+		return
+	}
+
+	job := metadataJob{
+		urlID:     url.ID,
+		alias:     url.Alias,
+		target:    url.OriginalURL,
+		link:      trace.LinkFromContext(ctx),
+		requestID: logging.RequestID(ctx),
+	}
+
+	select {
+	case mu.jobs <- job:
+	default:
+		metrics.MetadataFetches.WithLabelValues("dropped").Inc()
+		logging.FromContext(ctx, mu.logger).Warn().Str("alias", url.Alias).Msg("metadata queue full, fetch dropped")
+	}
+}
+
+func (mu *metadataUsecase) worker() {
+	defer mu.wg.Done()
+	for job := range mu.jobs {
+		if err := mu.process(job); err != nil {
+			mu.logger.Warn().Err(err).Str("alias", job.alias).Str("request_id", job.requestID).Msg("failed to store link metadata")
+		}
+	}
+}
+
+// process fetches and stores the metadata of one link. A failed fetch is
+// stored too, so the link detail shows why there is no preview data.
+func (mu *metadataUsecase) process(job metadataJob) (err error) {
+	ctx, span := tracer.Start(context.Background(), "metadataUsecase.process",
+		trace.WithNewRoot(),
+		trace.WithLinks(job.link),
+		trace.WithAttributes(attribute.String("url.alias", job.alias)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, mu.timeout)
+	meta, fetchErr := mu.fetcher.Fetch(fetchCtx, job.target)
+	cancel()
+	if fetchErr != nil {
+		metrics.MetadataFetches.WithLabelValues("error").Inc()
+		meta = &domain.Metadata{Error: fetchErr.Error()}
+	} else {
+		metrics.MetadataFetches.WithLabelValues("ok").Inc()
+	}
+	meta.FetchedAt = time.Now()
+
+	return mu.urlRepo.SaveMetadata(ctx, job.urlID, meta)
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+)
+
+const (
+	maxOpenGraphTitle       = 200
+	maxOpenGraphDescription = 500
+)
+
+func normalizeOpenGraph(og domain.OpenGraph) domain.OpenGraph {
+	return domain.OpenGraph{
+		Title:       strings.TrimSpace(og.Title),
+		Description: strings.TrimSpace(og.Description),
+		ImageURL:    strings.TrimSpace(og.ImageURL),
+	}
+}
+
+func validateOpenGraph(og domain.OpenGraph) error {
+	if utf8.RuneCountInString(og.Title) > maxOpenGraphTitle {
+		return fmt.Errorf("%w: title must be at most %d characters", ErrInvalidOpenGraph, maxOpenGraphTitle)
+	}
+	if utf8.RuneCountInString(og.Description) > maxOpenGraphDescription {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidOpenGraph, maxOpenGraphDescription)
+	}
+	if og.ImageURL != "" {
+		u, err := url.ParseRequestURI(og.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: image must be an absolute http(s) url", ErrInvalidOpenGraph)
+		}
+	}
+	return nil
+}
+
+// unfurl builds the card shown to social crawlers. Fields the owner left
+// empty are taken from the destination metadata, unless the link is
+// protected and the destination must not leak.
+func (u *urlUsecase) unfurl(ctx context.Context, url *domain.URL) *domain.OpenGraph {
+	og := url.OpenGraph
+	if url.PasswordHash != "" || url.RequireSignature {
+		return &og
+	}
+
+	meta, err := u.urlRepo.GetMetadata(ctx, url.ID)
+	if err != nil {
+		logging.FromContext(ctx, u.logger).Debug().Err(err).Str("alias", url.Alias).Msg("no metadata for unfurl")
+		return &og
+	}
+	if og.Title == "" {
+		og.Title = meta.Title
+	}
+	if og.Description == "" {
+		og.Description = meta.Description
+	}
+	if og.ImageURL == "" {
+		og.ImageURL = meta.ImageURL
+	}
+	return &og
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 4
+	// bcrypt ignores everything past 72 bytes.
+	maxPasswordLength = 72
+
+	attemptsKeyPrefix = "pwattempts:"
+)
+
+type PasswordOptions struct {
+	// Secret signs unlock tokens; it must be shared by all instances.
+	Secret []byte
+	// TokenTTL is how long a visitor stays unlocked after a correct password.
+	TokenTTL time.Duration
+	// MaxAttempts password submissions are allowed per alias and IP within
+	// AttemptWindow.
+	MaxAttempts   int
+	AttemptWindow time.Duration
+}
+
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", fmt.Errorf("%w: password must be %d-%d bytes", ErrInvalidPassword, minPasswordLength, maxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// unlockToken is "<expiry unix>.<mac>". The password hash is part of the MAC
+// so that changing the password revokes outstanding tokens.
+func (u *urlUsecase) unlockToken(url *domain.URL, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + u.unlockMAC(url, exp)
+}
+
+func (u *urlUsecase) unlockMAC(url *domain.URL, exp string) string {
+	mac := hmac.New(sha256.New, u.password.Secret)
+	mac.Write([]byte(url.Alias + "|" + exp + "|" + url.PasswordHash))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (u *urlUsecase) validUnlockToken(url *domain.URL, token string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(u.unlockMAC(url, exp)))
+}
+
+// UnlockURL checks a password submitted for a protected link and returns a
+// token that lets the visitor through until it expires. Submissions are
+// throttled per alias and IP whether or not they succeed.
+func (u *urlUsecase) UnlockURL(ctx context.Context, alias, password, ip string) (_ string, _ time.Time, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.UnlockURL", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := u.lookupURL(ctx, alias)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if url.PasswordHash == "" {
+		return "", time.Time{}, fmt.Errorf("%w: alias %s is not password protected", ErrNotFound, alias)
+	}
+
+	attemptsKey := attemptsKeyPrefix + alias + ":" + ip
+	attempts, err := u.cache.Incr(ctx, attemptsKey, u.password.AttemptWindow)
+	if err != nil {
+		// Refuse rather than allow unthrottled guessing.
+		return "", time.Time{}, fmt.Errorf("failed to count password attempts: %w", err)
+	}
+	if attempts > int64(u.password.MaxAttempts) {
+		return "", time.Time{}, fmt.Errorf("%w: alias %s", ErrTooManyAttempts, alias)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(url.PasswordHash), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return "", time.Time{}, fmt.Errorf("%w: alias %s", ErrWrongPassword, alias)
+		}
+		return "", time.Time{}, fmt.Errorf("failed to compare password: %w", err)
+	}
+
+	if err := u.cache.Delete(ctx, attemptsKey); err != nil {
+		logging.FromContext(ctx, u.logger).Warn().Err(err).Str("alias", alias).Msg("failed to reset password attempts")
+	}
+
+	expires := time.Now().Add(u.password.TokenTTL)
+	return u.unlockToken(url, expires), expires, nil
+}
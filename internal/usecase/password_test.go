@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+)
+
+func TestValidUnlockToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+
+	u := &urlUsecase{password: PasswordOptions{Secret: []byte("secret")}}
+	link := &domain.URL{Alias: "abc123", PasswordHash: "$2a$10$old"}
+	token := u.unlockToken(link, expires)
+	exp, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		usecase *urlUsecase
+		link    *domain.URL
+		token   string
+		now     time.Time
+		want    bool
+	}{
+		{name: "valid", usecase: u, link: link, token: token, now: now, want: true},
+		{name: "expired", usecase: u, link: link, token: token, now: expires},
+		{name: "extended expiry", usecase: u, link: link, token: strconv.FormatInt(expires.Add(24*time.Hour).Unix(), 10) + "." + sig, now: now},
+		{name: "tampered mac", usecase: u, link: link, token: exp + "." + strings.Repeat("A", len(sig)), now: now},
+		{name: "malformed", usecase: u, link: link, token: sig, now: now},
+		{name: "empty", usecase: u, link: link, token: "", now: now},
+		{name: "other alias", usecase: u, link: &domain.URL{Alias: "xyz789", PasswordHash: link.PasswordHash}, token: token, now: now},
+		{name: "password changed", usecase: u, link: &domain.URL{Alias: link.Alias, PasswordHash: "$2a$10$new"}, token: token, now: now},
+		{name: "other secret", usecase: &urlUsecase{password: PasswordOptions{Secret: []byte("other")}}, link: link, token: token, now: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.usecase.validUnlockToken(tt.link, tt.token, tt.now); got != tt.want {
+				t.Errorf("validUnlockToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+)
+
+type PreviewOptions struct {
+	Fetcher PageFetcher
+	// Queue fetches the metadata of new links in the background.
+	Queue MetadataQueue
+	// CacheTTL is how long fetched metadata is reused, failures included.
+	CacheTTL time.Duration
+	// Timeout bounds the fetch done while the visitor waits.
+	Timeout time.Duration
+}
+
+const metadataCachePrefix = "metadata:"
+
+// destinationMetadata describes target for the preview page. The metadata
+// stored when the link was created is used when the visit resolved to the
+// link's own URL; other destinations are fetched on demand. It never fails:
+// a preview without a title is better than no preview.
+func (u *urlUsecase) destinationMetadata(ctx context.Context, url *domain.URL, destination, target string) *domain.Metadata {
+	log := logging.FromContext(ctx, u.logger)
+
+	if destination == url.OriginalURL {
+		meta, err := u.urlRepo.GetMetadata(ctx, url.ID)
+		if err == nil && meta.Error == "" {
+			return meta
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			log.Warn().Err(err).Str("alias", url.Alias).Msg("failed to load link metadata")
+		}
+	}
+
+	key := metadataCachePrefix + target
+
+	if data, err := u.cache.Get(ctx, key); err == nil {
+		var meta domain.Metadata
+		if err := json.Unmarshal([]byte(data), &meta); err == nil {
+			return &meta
+		}
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, u.preview.Timeout)
+	defer cancel()
+
+	meta, err := u.preview.Fetcher.Fetch(fetchCtx, target)
+	if err != nil {
+		log.Debug().Err(err).Str("target", target).Msg("failed to fetch destination metadata")
+		meta = &domain.Metadata{}
+	}
+
+	// Failures are cached too, so a slow destination does not slow down
+	// every preview.
+	if data, err := json.Marshal(meta); err == nil {
+		if err := u.cache.Set(ctx, key, string(data), u.preview.CacheTTL); err != nil {
+			log.Warn().Err(err).Msg("failed to cache destination metadata")
+		}
+	}
+	return meta
+}
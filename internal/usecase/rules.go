@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+	"url-shortener-wb/internal/useragent"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxRulesPerLink = 20
+
+var (
+	countryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+
+	knownOS = map[string]bool{
+		domain.OSIOS:      true,
+		domain.OSAndroid:  true,
+		domain.OSWindows:  true,
+		domain.OSMacOS:    true,
+		domain.OSLinux:    true,
+		domain.OSChromeOS: true,
+	}
+	knownDevices = map[string]bool{
+		domain.DeviceMobile:  true,
+		domain.DeviceTablet:  true,
+		domain.DeviceDesktop: true,
+	}
+)
+
+func normalizeRules(rules []domain.Rule) []domain.Rule {
+	out := make([]domain.Rule, len(rules))
+	for i, rule := range rules {
+		rule.OS = strings.ToLower(strings.TrimSpace(rule.OS))
+		rule.Device = strings.ToLower(strings.TrimSpace(rule.Device))
+		rule.Country = strings.ToUpper(strings.TrimSpace(rule.Country))
+		out[i] = rule
+	}
+	return out
+}
+
+func validateRules(rules []domain.Rule) error {
+	if len(rules) > maxRulesPerLink {
+		return fmt.Errorf("%w: at most %d rules per link", ErrInvalidRule, maxRulesPerLink)
+	}
+	for i, rule := range rules {
+		if rule.OS == "" && rule.Device == "" && rule.Country == "" {
+			return fmt.Errorf("%w: rule %d has no conditions", ErrInvalidRule, i)
+		}
+		if rule.OS != "" && !knownOS[rule.OS] {
+			return fmt.Errorf("%w: rule %d has unknown os %q", ErrInvalidRule, i, rule.OS)
+		}
+		if rule.Device != "" && !knownDevices[rule.Device] {
+			return fmt.Errorf("%w: rule %d has unknown device %q", ErrInvalidRule, i, rule.Device)
+		}
+		if rule.Country != "" && !countryCodeRegex.MatchString(rule.Country) {
+			return fmt.Errorf("%w: rule %d country must be an ISO 3166-1 alpha-2 code", ErrInvalidRule, i)
+		}
+		if err := validateURL(rule.Target); err != nil {
+			return fmt.Errorf("%w: rule %d target: %v", ErrInvalidRule, i, err)
+		}
+	}
+	return nil
+}
+
+// matchRule returns the first rule matching the visitor, or nil when the
+// default destination should be used. Rules are expected in priority order.
+func matchRule(rules []domain.Rule, visitor domain.Visitor) *domain.Rule {
+	for i := range rules {
+		if rules[i].Matches(visitor) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func (u *urlUsecase) GetRules(ctx context.Context, alias string) (_ []domain.Rule, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetRules", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := u.lookupURL(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	return url.Rules, nil
+}
+
+func (u *urlUsecase) ReplaceRules(ctx context.Context, alias string, rules []domain.Rule) (_ []domain.Rule, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ReplaceRules", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+		attribute.Int("rules.count", len(rules)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	rules = normalizeRules(rules)
+	if err := validateRules(rules); err != nil {
+		return nil, err
+	}
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	if err := u.urlRepo.ReplaceRules(ctx, url.ID, rules); err != nil {
+		return nil, fmt.Errorf("failed to replace rules: %w", err)
+	}
+
+	u.invalidateURL(ctx, alias)
+
+	return sortedRules(rules), nil
+}
+
+func sortedRules(rules []domain.Rule) []domain.Rule {
+	sorted := append([]domain.Rule(nil), rules...)
+	slices.SortStableFunc(sorted, func(a, b domain.Rule) int {
+		return a.Priority - b.Priority
+	})
+	return sorted
+}
+
+// visitor describes the request for rule matching. The GeoIP lookup is only
+// done when the link actually has country rules.
+func (u *urlUsecase) visitor(url *domain.URL, req domain.RedirectRequest) domain.Visitor {
+	v := useragent.Parse(req.UserAgent)
+	for _, rule := range url.Rules {
+		if rule.Country != "" {
+			v.Country = u.geo.Country(req.IP)
+			break
+		}
+	}
+	return v
+}
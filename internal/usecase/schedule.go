@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxScheduleEntries = 50
+
+func validateWindow(from, until time.Time) error {
+	if !from.IsZero() && !until.IsZero() && !until.After(from) {
+		return fmt.Errorf("%w: active_until must be after active_from", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// normalizeSchedule trims targets and orders entries by start time.
+func normalizeSchedule(schedule []domain.ScheduleEntry) []domain.ScheduleEntry {
+	out := make([]domain.ScheduleEntry, len(schedule))
+	for i, entry := range schedule {
+		entry.ID = 0
+		entry.Target = strings.TrimSpace(entry.Target)
+		out[i] = entry
+	}
+	slices.SortStableFunc(out, func(a, b domain.ScheduleEntry) int {
+		return a.StartsAt.Compare(b.StartsAt)
+	})
+	return out
+}
+
+func validateSchedule(schedule []domain.ScheduleEntry) error {
+	if len(schedule) > maxScheduleEntries {
+		return fmt.Errorf("%w: at most %d schedule entries per link", ErrInvalidSchedule, maxScheduleEntries)
+	}
+	for i, entry := range schedule {
+		if entry.StartsAt.IsZero() {
+			return fmt.Errorf("%w: entry %d has no starts_at", ErrInvalidSchedule, i)
+		}
+		if i > 0 && entry.StartsAt.Equal(schedule[i-1].StartsAt) {
+			return fmt.Errorf("%w: two entries start at %s", ErrInvalidSchedule, entry.StartsAt.Format(time.RFC3339))
+		}
+		if err := validateURL(entry.Target); err != nil {
+			return fmt.Errorf("%w: entry %d target: %v", ErrInvalidSchedule, i, err)
+		}
+	}
+	return nil
+}
+
+func (u *urlUsecase) GetSchedule(ctx context.Context, alias string) (_ []domain.ScheduleEntry, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetSchedule", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := u.lookupURL(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	return url.Schedule, nil
+}
+
+func (u *urlUsecase) ReplaceSchedule(ctx context.Context, alias string, schedule []domain.ScheduleEntry) (_ []domain.ScheduleEntry, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ReplaceSchedule", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+		attribute.Int("schedule.count", len(schedule)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	schedule = normalizeSchedule(schedule)
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	if err := u.urlRepo.ReplaceSchedule(ctx, url.ID, schedule); err != nil {
+		return nil, fmt.Errorf("failed to replace schedule: %w", err)
+	}
+
+	u.invalidateURL(ctx, alias)
+
+	return schedule, nil
+}
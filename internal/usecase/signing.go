@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/signing"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SigningOptions struct {
+	Signer LinkSigner
+	// MaxTTL caps how long a minted link stays valid.
+	MaxTTL time.Duration
+}
+
+// checkSignature verifies the signature parameters of a visit to a link
+// that requires a signature or a password, and strips them from the query.
+// It returns the signature expiry, or the zero time for an unsigned visit.
+func (u *urlUsecase) checkSignature(url *domain.URL, req domain.RedirectRequest, now time.Time) (time.Time, error) {
+	if !url.RequireSignature && url.PasswordHash == "" {
+		return time.Time{}, nil
+	}
+	if !req.Query.Has(signing.ParamSignature) {
+		if url.RequireSignature {
+			return time.Time{}, fmt.Errorf("%w: alias %s requires a signed link", ErrInvalidSignature, url.Alias)
+		}
+		return time.Time{}, nil
+	}
+
+	expires, err := u.signing.Signer.Verify(url.Alias, req.Query, now)
+	if err != nil {
+		if errors.Is(err, signing.ErrExpired) {
+			return time.Time{}, fmt.Errorf("%w: alias %s", ErrSignatureExpired, url.Alias)
+		}
+		return time.Time{}, fmt.Errorf("%w: alias %s: %v", ErrInvalidSignature, url.Alias, err)
+	}
+	signing.Strip(req.Query)
+	return expires, nil
+}
+
+// SignLink mints the query parameters of a signed link to alias that is
+// valid for ttl.
+func (u *urlUsecase) SignLink(ctx context.Context, alias string, ttl time.Duration) (_ url.Values, _ time.Time, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.SignLink", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+		attribute.String("signing.ttl", ttl.String()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if ttl <= 0 || ttl > u.signing.MaxTTL {
+		return nil, time.Time{}, fmt.Errorf("%w: ttl must be between 1s and %s", ErrInvalidSignatureTTL, u.signing.MaxTTL)
+	}
+
+	if _, err := u.lookupURL(ctx, alias); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	params, err := u.signing.Signer.Sign(alias, expires)
+	if err != nil {
+		if errors.Is(err, signing.ErrDisabled) {
+			return nil, time.Time{}, ErrSigningDisabled
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to sign link: %w", err)
+	}
+	return params, expires, nil
+}
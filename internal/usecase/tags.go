@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+	repo "url-shortener-wb/internal/repository"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxTagsPerLink = 20
+
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// normalizeTags lower-cases and trims tags, dropping empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func validateTags(tags []string) error {
+	if len(tags) > maxTagsPerLink {
+		return fmt.Errorf("%w: at most %d tags per link", ErrInvalidTag, maxTagsPerLink)
+	}
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTag(tag string) error {
+	if !tagRegex.MatchString(tag) {
+		return fmt.Errorf("%w: %q must be 1-50 lower-case letters, digits, '-' or '_'", ErrInvalidTag, tag)
+	}
+	return nil
+}
+
+// normalizeTag prepares a single tag name taken from a request path or body.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return tag, validateTag(tag)
+}
+
+func (u *urlUsecase) ListTags(ctx context.Context) (_ []domain.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ListTags")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := u.urlRepo.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// CreateTag registers a tag before any link carries it, so that it can be
+// offered to the users.
+func (u *urlUsecase) CreateTag(ctx context.Context, name string) (_ *domain.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.CreateTag", trace.WithAttributes(
+		attribute.String("tag.name", name),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if name, err = normalizeTag(name); err != nil {
+		return nil, err
+	}
+
+	tag, err := u.urlRepo.CreateTag(ctx, name)
+	if err != nil {
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			return nil, fmt.Errorf("%w: %s", ErrTagExists, name)
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return tag, nil
+}
+
+// RenameTag renames a tag on every link carrying it.
+func (u *urlUsecase) RenameTag(ctx context.Context, name, newName string) (err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.RenameTag", trace.WithAttributes(
+		attribute.String("tag.name", name),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if newName, err = normalizeTag(newName); err != nil {
+		return err
+	}
+	if newName == name {
+		return nil
+	}
+
+	if err := u.urlRepo.RenameTag(ctx, name, newName); err != nil {
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			return fmt.Errorf("%w: %s", ErrTagExists, newName)
+		}
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: tag %s not found", ErrNotFound, name)
+		}
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return nil
+}
+
+// DeleteTag removes a tag from every link carrying it.
+func (u *urlUsecase) DeleteTag(ctx context.Context, name string) (err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.DeleteTag", trace.WithAttributes(
+		attribute.String("tag.name", name),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if err := u.urlRepo.DeleteTag(ctx, name); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: tag %s not found", ErrNotFound, name)
+		}
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// ReplaceLinkTags swaps the tags of a link and returns them normalized.
+func (u *urlUsecase) ReplaceLinkTags(ctx context.Context, alias string, tags []string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ReplaceLinkTags", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+		attribute.Int("tags.count", len(tags)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	tags = normalizeTags(tags)
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	if err := u.urlRepo.ReplaceTags(ctx, url.ID, tags); err != nil {
+		return nil, fmt.Errorf("failed to replace url tags: %w", err)
+	}
+	return tags, nil
+}
+
+// AssignTags adds and removes tags on many links at once and returns the
+// aliases that do not exist. Either every found link is changed or, when
+// one of them would exceed the tag limit, none is.
+func (u *urlUsecase) AssignTags(ctx context.Context, aliases, add, remove []string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.AssignTags", trace.WithAttributes(
+		attribute.Int("aliases.count", len(aliases)),
+		attribute.Int("tags.added", len(add)),
+		attribute.Int("tags.removed", len(remove)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	aliases = slices.Compact(slices.Sorted(slices.Values(aliases)))
+	if len(aliases) == 0 || aliases[0] == "" {
+		return nil, fmt.Errorf("%w: aliases must not be empty", ErrInvalidAlias)
+	}
+	if len(aliases) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d links at once", ErrInvalidBatch, MaxBatchSize)
+	}
+
+	add, remove = normalizeTags(add), normalizeTags(remove)
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, fmt.Errorf("%w: nothing to add or remove", ErrInvalidTag)
+	}
+	if err := validateTags(add); err != nil {
+		return nil, err
+	}
+	for _, tag := range remove {
+		if slices.Contains(add, tag) {
+			return nil, fmt.Errorf("%w: %q is both added and removed", ErrInvalidTag, tag)
+		}
+	}
+
+	found, err := u.urlRepo.AssignTags(ctx, aliases, add, remove, maxTagsPerLink)
+	if err != nil {
+		if errors.Is(err, repo.ErrLimitExceeded) {
+			return nil, fmt.Errorf("%w: at most %d tags per link", ErrInvalidTag, maxTagsPerLink)
+		}
+		return nil, fmt.Errorf("failed to assign tags: %w", err)
+	}
+
+	updated := make(map[string]bool, len(found))
+	for _, alias := range found {
+		updated[alias] = true
+	}
+	var missing []string
+	for _, alias := range aliases {
+		if !updated[alias] {
+			missing = append(missing, alias)
+		}
+	}
+	span.SetAttributes(attribute.Int("aliases.missing", len(missing)))
+	return missing, nil
+}
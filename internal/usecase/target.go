@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+)
+
+// buildTarget applies the link's forwarding rules to the stored destination:
+// an optional trailing path suffix is appended to the destination path and
+// incoming query parameters are merged according to link.QueryMerge.
+func buildTarget(link *domain.URL, destination string, req domain.RedirectRequest) (string, error) {
+	forwardPath := link.ForwardPath && req.PathSuffix != ""
+	forwardQuery := link.ForwardQuery && len(req.Query) > 0
+	if !forwardPath && !forwardQuery {
+		return destination, nil
+	}
+
+	target, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse destination %q: %w", destination, err)
+	}
+
+	if forwardPath {
+		// Cleaning against "/" keeps ".." segments from escaping the
+		// destination path.
+		suffix := path.Clean("/" + req.PathSuffix)
+		target.Path = strings.TrimSuffix(target.Path, "/") + suffix
+		target.RawPath = ""
+	}
+
+	if forwardQuery {
+		target.RawQuery = mergeQuery(target.Query(), req.Query, link.QueryMerge).Encode()
+	}
+
+	return target.String(), nil
+}
+
+func mergeQuery(stored, incoming url.Values, mode domain.QueryMerge) url.Values {
+	merged := make(url.Values, len(stored)+len(incoming))
+	for key, values := range stored {
+		merged[key] = append([]string(nil), values...)
+	}
+
+	for key, values := range incoming {
+		_, conflict := merged[key]
+		switch {
+		case !conflict:
+			merged[key] = append([]string(nil), values...)
+		case mode == domain.QueryMergeAppend:
+			merged[key] = append(merged[key], values...)
+		case mode == domain.QueryMergeLink:
+			// The stored value wins.
+		default:
+			merged[key] = append([]string(nil), values...)
+		}
+	}
+	return merged
+}
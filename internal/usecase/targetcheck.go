@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/tracing"
+
+	"github.com/wb-go/wbf/zlog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TargetCheckOptions struct {
+	// Interval between check rounds; zero disables the checker.
+	Interval time.Duration
+	// Timeout of a single probe.
+	Timeout time.Duration
+	// FailureThreshold consecutive failed probes flag a target as down.
+	FailureThreshold int
+	// BatchSize links are probed per round.
+	BatchSize int
+	// Client sends the probes; it must keep them away from internal
+	// services.
+	Client *http.Client
+}
+
+// targetChecker periodically probes the default destination of links that
+// have a fallback URL, so that visitors are sent to the fallback while the
+// destination is down.
+type targetChecker struct {
+	urlRepo URLRepository
+	cache   Cache
+	client  *http.Client
+	opts    TargetCheckOptions
+	logger  *zlog.Zerolog
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func NewTargetChecker(
+	urlRepo URLRepository,
+	cache Cache,
+	opts TargetCheckOptions,
+	logger *zlog.Zerolog,
+) *targetChecker {
+	return &targetChecker{
+		urlRepo: urlRepo,
+		cache:   cache,
+		client:  opts.Client,
+		opts:    opts,
+		logger:  logger,
+		stop:    make(chan struct{}),
+	}
+}
+
+func (tc *targetChecker) Start() {
+	if tc.opts.Interval <= 0 {
+		return
+	}
+	tc.wg.Add(1)
+	go tc.loop()
+}
+
+func (tc *targetChecker) Stop(ctx context.Context) error {
+	tc.once.Do(func() { close(tc.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		tc.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("target checker not stopped: %w", ctx.Err())
+	}
+}
+
+func (tc *targetChecker) loop() {
+	defer tc.wg.Done()
+
+	ticker := time.NewTicker(tc.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-tc.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				select {
+				case <-tc.stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			if err := tc.checkBatch(ctx); err != nil {
+				tc.logger.Warn().Err(err).Msg("target check round failed")
+			}
+			cancel()
+		}
+	}
+}
+
+func (tc *targetChecker) checkBatch(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "targetChecker.checkBatch", trace.WithNewRoot())
+	defer func() { tracing.End(span, err) }()
+
+	urls, err := tc.urlRepo.ListTargetsToCheck(ctx, tc.opts.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list links to check: %w", err)
+	}
+	span.SetAttributes(attribute.Int("targets.count", len(urls)))
+
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			return nil
+		}
+		tc.check(ctx, url)
+	}
+	return nil
+}
+
+func (tc *targetChecker) check(ctx context.Context, url *domain.URL) {
+	schedule, err := tc.urlRepo.GetSchedule(ctx, url.ID)
+	if err != nil {
+		tc.logger.Warn().Err(err).Str("alias", url.Alias).Msg("failed to load schedule for target check")
+		return
+	}
+	url.Schedule = schedule
+	target := url.ScheduledTarget(time.Now())
+
+	ok := tc.probe(ctx, target)
+	if ok {
+		metrics.TargetChecks.WithLabelValues("up").Inc()
+	} else {
+		metrics.TargetChecks.WithLabelValues("down").Inc()
+	}
+
+	down, changed, err := tc.urlRepo.RecordTargetCheck(ctx, url.ID, ok, tc.opts.FailureThreshold)
+	if err != nil {
+		metrics.TargetChecks.WithLabelValues("error").Inc()
+		tc.logger.Warn().Err(err).Str("alias", url.Alias).Msg("failed to record target check")
+		return
+	}
+	if !changed {
+		return
+	}
+
+	tc.logger.Info().Str("alias", url.Alias).Str("target", target).Bool("down", down).Msg("link target status changed")
+	if err := tc.cache.Delete(ctx, url.Alias); err != nil {
+		tc.logger.Warn().Err(err).Str("alias", url.Alias).Msg("failed to invalidate cached URL")
+	}
+}
+
+// probe reports whether target answers. Auth walls and rate limits count
+// as up: the site is there, it just does not serve an anonymous probe.
+func (tc *targetChecker) probe(ctx context.Context, target string) bool {
+	status, err := tc.request(ctx, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = tc.request(ctx, http.MethodGet, target)
+	}
+	if err != nil {
+		return false
+	}
+
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return status < http.StatusBadRequest
+}
+
+func (tc *targetChecker) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "url-shortener-target-check/1.0")
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -8,42 +8,85 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/metrics"
+	"url-shortener-wb/internal/tracing"
+	"url-shortener-wb/internal/useragent"
 
 	"github.com/wb-go/wbf/zlog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const maxOwnerLength = 100
+
 var (
 	customAliasRegex = regexp.MustCompile(`^[a-zA-Z0-9]{3,20}$`)
+	// importedAliasRegex also admits the aliases of other shorteners, so
+	// that migrated links keep their exact short URLs.
+	importedAliasRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	tracer             = tracing.Tracer("url-shortener-wb/internal/usecase")
 )
 
 type urlUsecase struct {
-	urlRepo URLRepository
-	cache   Cache
-	logger  *zlog.Zerolog
+	urlRepo  URLRepository
+	cache    Cache
+	geo      GeoLocator
+	signing  SigningOptions
+	password PasswordOptions
+	preview  PreviewOptions
+	logger   *zlog.Zerolog
 }
 
 func NewURLUsecase(
 	urlRepo URLRepository,
 	cache Cache,
+	geo GeoLocator,
+	signing SigningOptions,
+	password PasswordOptions,
+	preview PreviewOptions,
 	logger *zlog.Zerolog,
 ) *urlUsecase {
 	return &urlUsecase{
-		urlRepo: urlRepo,
-		cache:   cache,
-		logger:  logger,
+		urlRepo:  urlRepo,
+		cache:    cache,
+		geo:      geo,
+		signing:  signing,
+		password: password,
+		preview:  preview,
+		logger:   logger,
 	}
 }
 
+// validateURL admits absolute http(s) URLs only: targets end up in
+// Location headers and redirect pages, where javascript: or data: URLs
+// would run on the shortener's origin.
 func validateURL(originalURL string) error {
-	if _, err := url.ParseRequestURI(originalURL); err != nil {
+	u, err := url.ParseRequestURI(originalURL)
+	if err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
 	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: must be an absolute http(s) url", ErrInvalidURL)
+	}
 	return nil
 }
 
+// reservedAliases are the static routes under /api/v1/links/ that would
+// shadow a link of the same name at /api/v1/links/{alias}. They cannot be
+// used as aliases in any letter case.
+var reservedAliases = map[string]bool{
+	"batch":  true,
+	"import": true,
+	"export": true,
+	"tags":   true,
+}
+
 func validateCustomAlias(alias string) error {
 	if alias == "" {
 		return nil
@@ -51,75 +94,530 @@ func validateCustomAlias(alias string) error {
 	if !customAliasRegex.MatchString(alias) {
 		return fmt.Errorf("%w: alias must be 3-20 alphanumeric characters", ErrInvalidAlias)
 	}
+	return checkReservedAlias(alias)
+}
+
+func validateImportedAlias(alias string) error {
+	if alias == "" {
+		return nil
+	}
+	if !importedAliasRegex.MatchString(alias) {
+		return fmt.Errorf("%w: alias must be 1-64 letters, digits, '-' or '_'", ErrInvalidAlias)
+	}
+	return checkReservedAlias(alias)
+}
+
+func checkReservedAlias(alias string) error {
+	if reservedAliases[strings.ToLower(alias)] {
+		return fmt.Errorf("%w: alias %q is reserved", ErrInvalidAlias, alias)
+	}
 	return nil
 }
 
-func (u *urlUsecase) CreateShortURL(ctx context.Context, originalURL, customAlias string) (string, error) {
-	if err := validateURL(originalURL); err != nil {
-		return "", err
+func validateRedirectType(t domain.RedirectType) error {
+	if !t.Valid() {
+		return fmt.Errorf("%w: %q, expected one of 301, 302, 307, 308, meta", ErrInvalidRedirectType, t)
 	}
+	return nil
+}
 
-	if err := validateCustomAlias(customAlias); err != nil {
-		return "", err
+func validateQueryMerge(m domain.QueryMerge) error {
+	if !m.Valid() {
+		return fmt.Errorf("%w: %q, expected one of request, link, append", ErrInvalidQueryMerge, m)
 	}
+	return nil
+}
 
-	alias := customAlias
-	if alias == "" {
-		b := make([]byte, 8)
-		if _, err := rand.Read(b); err != nil {
-			return "", fmt.Errorf("failed to generate random alias: %w", err)
+func validateClickLimit(maxClicks int, fallbackURL string) error {
+	if maxClicks < 0 {
+		return fmt.Errorf("%w: max_clicks must not be negative", ErrInvalidClickLimit)
+	}
+	if fallbackURL != "" {
+		if err := validateURL(fallbackURL); err != nil {
+			return fmt.Errorf("fallback url: %w", err)
 		}
-		alias = base64.RawURLEncoding.EncodeToString(b)[:6]
 	}
+	return nil
+}
+
+func (u *urlUsecase) CreateShortURL(ctx context.Context, in domain.CreateURLInput) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.CreateShortURL", trace.WithAttributes(
+		attribute.String("url.custom_alias", in.CustomAlias),
+	))
+	defer func() { tracing.End(span, err) }()
 
-	exists, err := u.urlRepo.ExistsByAlias(ctx, alias)
+	if err := validateCustomAlias(in.CustomAlias); err != nil {
+		return "", err
+	}
+	url, err := newURL(in)
 	if err != nil {
-		return "", fmt.Errorf("failed to check alias existence: %w", err)
+		return "", err
+	}
+	if err := u.checkFolder(ctx, url.FolderID); err != nil {
+		return "", err
+	}
+	if url.Alias == "" {
+		if url.Alias, err = randomAlias(); err != nil {
+			return "", err
+		}
 	}
 
-	if exists {
-		return "", fmt.Errorf("%w: alias %s", ErrAliasExists, alias)
+	span.SetAttributes(attribute.String("url.alias", url.Alias))
+
+	exists, err := u.urlRepo.ExistsByAlias(ctx, url.Alias)
+	if err != nil {
+		return "", fmt.Errorf("failed to check alias existence: %w", err)
 	}
 
-	url := &domain.URL{
-		OriginalURL: originalURL,
-		Alias:       alias,
-		CreatedAt:   time.Now(),
+	if exists {
+		return "", fmt.Errorf("%w: alias %s", ErrAliasExists, url.Alias)
 	}
 
 	if err := u.urlRepo.Create(ctx, url); err != nil {
 		return "", fmt.Errorf("failed to create url: %w", err)
 	}
 
-	if err := u.cache.Set(ctx, alias, originalURL); err != nil {
-		u.logger.Warn().Err(err).Str("alias", alias).Msg("failed to cache URL")
+	u.cacheURL(ctx, url)
+	u.preview.Queue.FetchMetadata(ctx, url)
+
+	return url.Alias, nil
+}
+
+// newURL validates a create request and builds the link it describes. The
+// custom alias is checked by the caller; it is left empty when none was
+// requested.
+func newURL(in domain.CreateURLInput) (*domain.URL, error) {
+	if err := validateURL(in.OriginalURL); err != nil {
+		return nil, err
+	}
+
+	if in.RedirectType == "" {
+		in.RedirectType = domain.DefaultRedirectType
+	}
+	if err := validateRedirectType(in.RedirectType); err != nil {
+		return nil, err
+	}
+
+	if in.QueryMerge == "" {
+		in.QueryMerge = domain.DefaultQueryMerge
 	}
+	if err := validateQueryMerge(in.QueryMerge); err != nil {
+		return nil, err
+	}
+
+	in.UTM = normalizeUTM(in.UTM)
+	if err := validateUTM(in.UTM); err != nil {
+		return nil, err
+	}
+	originalURL, err := applyUTM(in.OriginalURL, in.UTM)
+	if err != nil {
+		return nil, err
+	}
+
+	in.Rules = normalizeRules(in.Rules)
+	if err := validateRules(in.Rules); err != nil {
+		return nil, err
+	}
+
+	in.Locales = normalizeLocales(in.Locales)
+	if err := validateLocales(in.Locales); err != nil {
+		return nil, err
+	}
+
+	in.Split = normalizeSplit(in.Split)
+	if err := validateSplit(in.Split); err != nil {
+		return nil, err
+	}
+
+	if err := validateWindow(in.ActiveFrom, in.ActiveUntil); err != nil {
+		return nil, err
+	}
+	in.Schedule = normalizeSchedule(in.Schedule)
+	if err := validateSchedule(in.Schedule); err != nil {
+		return nil, err
+	}
+
+	if err := validateClickLimit(in.MaxClicks, in.FallbackURL); err != nil {
+		return nil, err
+	}
+
+	in.OpenGraph = normalizeOpenGraph(in.OpenGraph)
+	if err := validateOpenGraph(in.OpenGraph); err != nil {
+		return nil, err
+	}
+
+	in.Tags = normalizeTags(in.Tags)
+	if err := validateTags(in.Tags); err != nil {
+		return nil, err
+	}
+
+	in.Owner = strings.TrimSpace(in.Owner)
+	if utf8.RuneCountInString(in.Owner) > maxOwnerLength {
+		return nil, fmt.Errorf("%w: owner must be at most %d characters", ErrInvalidOwner, maxOwnerLength)
+	}
+
+	passwordHash, err := hashPassword(in.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.URL{
+		OriginalURL:      originalURL,
+		Alias:            in.CustomAlias,
+		RedirectType:     in.RedirectType,
+		ForwardQuery:     in.ForwardQuery,
+		ForwardPath:      in.ForwardPath,
+		QueryMerge:       in.QueryMerge,
+		UTM:              in.UTM,
+		Rules:            sortedRules(in.Rules),
+		Locales:          in.Locales,
+		Split:            in.Split,
+		ActiveFrom:       in.ActiveFrom,
+		ActiveUntil:      in.ActiveUntil,
+		Schedule:         in.Schedule,
+		MaxClicks:        in.MaxClicks,
+		FallbackURL:      in.FallbackURL,
+		PasswordHash:     passwordHash,
+		RequireSignature: in.RequireSignature,
+		Preview:          in.Preview,
+		OpenGraph:        in.OpenGraph,
+		Tags:             in.Tags,
+		Owner:            in.Owner,
+		FolderID:         in.FolderID,
+		CreatedAt:        time.Now(),
+	}, nil
+}
 
-	return alias, nil
+func randomAlias() (string, error) {
+	b := make([]byte, 8)
+	for {
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("failed to generate random alias: %w", err)
+		}
+		if alias := base64.RawURLEncoding.EncodeToString(b)[:6]; !reservedAliases[strings.ToLower(alias)] {
+			return alias, nil
+		}
+	}
 }
 
-func (u *urlUsecase) GetOriginalURL(ctx context.Context, alias string) (string, error) {
+func (u *urlUsecase) UpdateURL(ctx context.Context, alias string, in domain.UpdateURLInput) (_ *domain.URL, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.UpdateURL", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	if alias == "" {
-		return "", fmt.Errorf("%w: empty alias", ErrInvalidAlias)
+		return nil, fmt.Errorf("%w: empty alias", ErrInvalidAlias)
 	}
 
-	originalURL, err := u.cache.Get(ctx, alias)
-	if err == nil {
-		u.logger.Debug().Str("alias", alias).Msg("cache hit")
-		return originalURL, nil
+	if in.RedirectType != nil {
+		if err := validateRedirectType(*in.RedirectType); err != nil {
+			return nil, err
+		}
+	}
+	if in.QueryMerge != nil {
+		if err := validateQueryMerge(*in.QueryMerge); err != nil {
+			return nil, err
+		}
 	}
 
 	url, err := u.urlRepo.GetByAlias(ctx, alias)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			return "", fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
 		}
-		return "", fmt.Errorf("failed to get url by alias: %w", err)
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
 	}
 
-	if err := u.cache.Set(ctx, alias, url.OriginalURL); err != nil {
-		u.logger.Warn().Err(err).Str("alias", alias).Msg("failed to update cache")
+	if in.RedirectType != nil {
+		url.RedirectType = *in.RedirectType
 	}
+	if in.ForwardQuery != nil {
+		url.ForwardQuery = *in.ForwardQuery
+	}
+	if in.ForwardPath != nil {
+		url.ForwardPath = *in.ForwardPath
+	}
+	if in.QueryMerge != nil {
+		url.QueryMerge = *in.QueryMerge
+	}
+	if in.ActiveFrom != nil {
+		url.ActiveFrom = *in.ActiveFrom
+	}
+	if in.ActiveUntil != nil {
+		url.ActiveUntil = *in.ActiveUntil
+	}
+	if err := validateWindow(url.ActiveFrom, url.ActiveUntil); err != nil {
+		return nil, err
+	}
+	if in.MaxClicks != nil {
+		url.MaxClicks = *in.MaxClicks
+	}
+	if in.FallbackURL != nil {
+		url.FallbackURL = *in.FallbackURL
+	}
+	if err := validateClickLimit(url.MaxClicks, url.FallbackURL); err != nil {
+		return nil, err
+	}
+	if in.Disabled != nil {
+		url.Disabled = *in.Disabled
+	}
+	if in.Preview != nil {
+		url.Preview = *in.Preview
+	}
+	if in.OpenGraph != nil {
+		og := normalizeOpenGraph(*in.OpenGraph)
+		if err := validateOpenGraph(og); err != nil {
+			return nil, err
+		}
+		url.OpenGraph = og
+	}
+	if in.RequireSignature != nil {
+		url.RequireSignature = *in.RequireSignature
+	}
+	if in.FolderID != nil {
+		if err := u.checkFolder(ctx, *in.FolderID); err != nil {
+			return nil, err
+		}
+		url.FolderID = *in.FolderID
+	}
+	if in.Password != nil {
+		url.PasswordHash, err = hashPassword(*in.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.urlRepo.Update(ctx, url); err != nil {
+		return nil, fmt.Errorf("failed to update url: %w", err)
+	}
+
+	u.invalidateURL(ctx, alias)
+
+	return url, nil
+}
+
+// GetURL returns a link with the metadata of its destination, nil while it
+// has not been fetched yet.
+func (u *urlUsecase) GetURL(ctx context.Context, alias string) (_ *domain.URL, _ *domain.Metadata, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetURL", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	if url.Tags, err = u.urlRepo.GetTags(ctx, url.ID); err != nil {
+		return nil, nil, fmt.Errorf("failed to get url tags: %w", err)
+	}
+
+	meta, err := u.urlRepo.GetMetadata(ctx, url.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return url, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get url metadata: %w", err)
+	}
+	return url, meta, nil
+}
+
+func (u *urlUsecase) GetOriginalURL(ctx context.Context, req domain.RedirectRequest) (_ *domain.Redirect, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetOriginalURL", trace.WithAttributes(
+		attribute.String("url.alias", req.Alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if req.Alias == "" {
+		return nil, fmt.Errorf("%w: empty alias", ErrInvalidAlias)
+	}
+
+	url, err := u.lookupURL(ctx, req.Alias)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if url.Disabled {
+		return fallback(url, ErrLinkDisabled)
+	}
+	if url.NotYetActive(now) {
+		return nil, fmt.Errorf("%w: alias %s", ErrLinkNotActive, req.Alias)
+	}
+	if url.Expired(now) {
+		return fallback(url, ErrLinkExpired)
+	}
+
+	// The card only carries what the owner chose to publish, so crawlers
+	// get it even for protected links; unfurling is not a click.
+	if !url.OpenGraph.IsZero() && useragent.IsSocialCrawler(req.UserAgent) {
+		span.SetAttributes(attribute.Bool("redirect.unfurl", true))
+		return &domain.Redirect{Link: url, Unfurl: u.unfurl(ctx, url)}, nil
+	}
+
+	// A valid signature stands in for the password of a protected link.
+	signedUntil, err := u.checkSignature(url, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.PasswordHash != "" && signedUntil.IsZero() && !u.validUnlockToken(url, req.UnlockToken, now) {
+		return nil, fmt.Errorf("%w: alias %s", ErrPasswordRequired, req.Alias)
+	}
+
+	preview := req.Preview || (url.Preview && !req.Query.Has(domain.ParamSkipPreview))
+	req.Query.Del(domain.ParamSkipPreview)
+
+	if req.PathSuffix != "" && !url.ForwardPath {
+		return nil, fmt.Errorf("%w: alias %s does not forward paths", ErrNotFound, req.Alias)
+	}
+
+	// Device and geo rules take precedence over the visitor's language, and
+	// both over an A/B split of the default destination.
+	destination := url.ScheduledTarget(now)
+	rule := matchRule(url.Rules, u.visitor(url, req))
+	locale := ""
+	var variant *domain.Variant
+	if rule != nil {
+		destination = rule.Target
+		span.SetAttributes(attribute.String("redirect.rule", rule.Label()))
+	} else if locale = negotiateLocale(url.Locales, req.AcceptLanguage); locale != "" {
+		destination = url.Locales[locale]
+		span.SetAttributes(attribute.String("redirect.locale", locale))
+	} else if variant = u.chooseVariant(ctx, url, req); variant != nil {
+		destination = variant.Target
+		span.SetAttributes(attribute.String("redirect.variant", variant.Name))
+	}
+
+	target, err := buildTarget(url, destination, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build target url: %w", err)
+	}
+
+	// Rule, locale and variant targets are not probed, so only a default
+	// destination that is down is replaced.
+	if url.TargetDown && url.FallbackURL != "" && rule == nil && locale == "" && variant == nil {
+		span.SetAttributes(attribute.Bool("redirect.target_down", true))
+		return &domain.Redirect{Link: url, Target: url.FallbackURL, Fallback: true}, nil
+	}
+
+	// Previews and probes do not use up a click, so on a click-limited link
+	// they only check that one is left; an exhausted link stays exhausted.
+	probe := !preview && (req.Head || useragent.IsSocialCrawler(req.UserAgent))
+	if url.MaxClicks > 0 && (preview || probe) {
+		ok, err := u.urlRepo.HasClicksLeft(ctx, url.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check clicks left: %w", err)
+		}
+		if !ok {
+			return fallback(url, ErrLinkExhausted)
+		}
+	}
+
+	// A preview is not a visit: the click is consumed on continue.
+	if preview {
+		span.SetAttributes(attribute.Bool("redirect.preview", true))
+		return &domain.Redirect{
+			Link:     url,
+			Target:   target,
+			Preview:  true,
+			Metadata: u.destinationMetadata(ctx, url, destination, target),
+		}, nil
+	}
+
+	// A HEAD request or a User-Agent is trivial to fake, so a probe of a
+	// click-limited link never learns the target: only a consumed click does.
+	if probe {
+		span.SetAttributes(attribute.Bool("redirect.probe", true))
+		if url.MaxClicks > 0 {
+			return &domain.Redirect{Link: url, Probe: true}, nil
+		}
+	}
+
+	if url.MaxClicks > 0 {
+		ok, err := u.urlRepo.ConsumeClick(ctx, url.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume click: %w", err)
+		}
+		if !ok {
+			return fallback(url, ErrLinkExhausted)
+		}
+	}
+
+	return &domain.Redirect{
+		Link:       url,
+		Target:     target,
+		Rule:       rule,
+		Locale:     locale,
+		Variant:    variant,
+		ValidUntil: validUntil(url.NextBoundary(now), signedUntil),
+		Probe:      probe,
+	}, nil
+}
+
+// fallback sends the visitor to the link's fallback URL, or fails with
+// reason when the link has none.
+func fallback(url *domain.URL, reason error) (*domain.Redirect, error) {
+	if url.FallbackURL == "" {
+		return nil, fmt.Errorf("%w: alias %s", reason, url.Alias)
+	}
+	return &domain.Redirect{Link: url, Target: url.FallbackURL, Fallback: true}, nil
+}
+
+// validUntil returns the earlier of two optional deadlines.
+func validUntil(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// lookupURL reads the link from cache, falling back to the database and
+// repopulating the cache on a miss.
+func (u *urlUsecase) lookupURL(ctx context.Context, alias string) (*domain.URL, error) {
+	cached, ok := u.cachedURL(ctx, alias)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", ok))
+	if ok {
+		metrics.CacheRequests.WithLabelValues("hit").Inc()
+		logging.FromContext(ctx, u.logger).Debug().Str("alias", alias).Msg("cache hit")
+		return cached, nil
+	}
+	metrics.CacheRequests.WithLabelValues("miss").Inc()
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	url.Rules, err = u.urlRepo.GetRules(ctx, url.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url rules: %w", err)
+	}
+
+	url.Locales, err = u.urlRepo.GetLocales(ctx, url.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url locales: %w", err)
+	}
+
+	url.Split.Variants, err = u.urlRepo.GetVariants(ctx, url.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url variants: %w", err)
+	}
+
+	url.Schedule, err = u.urlRepo.GetSchedule(ctx, url.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url schedule: %w", err)
+	}
+
+	u.cacheURL(ctx, url)
 
-	return url.OriginalURL, nil
+	return url, nil
 }
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"url-shortener-wb/internal/domain"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+// stubURLRepository serves a single link. Methods the tests do not reach
+// are left to the embedded nil interface and panic if called.
+type stubURLRepository struct {
+	URLRepository
+	link       domain.URL
+	clicksLeft int
+	consumed   int
+}
+
+func (r *stubURLRepository) GetByAlias(_ context.Context, alias string) (*domain.URL, error) {
+	if alias != r.link.Alias {
+		return nil, ErrNotFound
+	}
+	link := r.link
+	return &link, nil
+}
+
+func (r *stubURLRepository) GetRules(context.Context, int64) ([]domain.Rule, error) {
+	return nil, nil
+}
+
+func (r *stubURLRepository) GetLocales(context.Context, int64) (map[string]string, error) {
+	return nil, nil
+}
+
+func (r *stubURLRepository) GetVariants(context.Context, int64) ([]domain.Variant, error) {
+	return nil, nil
+}
+
+func (r *stubURLRepository) GetSchedule(context.Context, int64) ([]domain.ScheduleEntry, error) {
+	return nil, nil
+}
+
+func (r *stubURLRepository) ConsumeClick(context.Context, int64) (bool, error) {
+	if r.clicksLeft == 0 {
+		return false, nil
+	}
+	r.clicksLeft--
+	r.consumed++
+	return true, nil
+}
+
+func (r *stubURLRepository) HasClicksLeft(context.Context, int64) (bool, error) {
+	return r.clicksLeft > 0, nil
+}
+
+// missCache never holds anything, so every lookup reads the repository.
+type missCache struct {
+	Cache
+}
+
+func (missCache) Get(context.Context, string) (string, error) {
+	return "", errors.New("cache miss")
+}
+
+func (missCache) Set(context.Context, string, string, time.Duration) error {
+	return nil
+}
+
+func TestGetOriginalURLClickLimit(t *testing.T) {
+	const (
+		target    = "https://example.com/offer"
+		fallback  = "https://example.com/sold-out"
+		browserUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"
+		botUA     = "TelegramBot (like TwitterBot)"
+	)
+
+	tests := []struct {
+		name         string
+		maxClicks    int
+		clicksLeft   int
+		fallbackURL  string
+		head         bool
+		userAgent    string
+		preview      bool
+		wantErr      error
+		wantTarget   string
+		wantFallback bool
+		wantProbe    bool
+		wantConsumed int
+	}{
+		{
+			name:         "visit consumes a click",
+			maxClicks:    1,
+			clicksLeft:   1,
+			userAgent:    browserUA,
+			wantTarget:   target,
+			wantConsumed: 1,
+		},
+		{
+			name:      "exhausted",
+			maxClicks: 1,
+			userAgent: browserUA,
+			wantErr:   ErrLinkExhausted,
+		},
+		{
+			name:         "exhausted with fallback",
+			maxClicks:    1,
+			fallbackURL:  fallback,
+			userAgent:    browserUA,
+			wantTarget:   fallback,
+			wantFallback: true,
+		},
+		{
+			name:       "head request does not consume or reveal",
+			maxClicks:  1,
+			clicksLeft: 1,
+			head:       true,
+			userAgent:  browserUA,
+			wantProbe:  true,
+		},
+		{
+			name:       "preview bot does not consume or reveal",
+			maxClicks:  1,
+			clicksLeft: 1,
+			userAgent:  botUA,
+			wantProbe:  true,
+		},
+		{
+			name:      "probe of exhausted link",
+			maxClicks: 1,
+			head:      true,
+			userAgent: browserUA,
+			wantErr:   ErrLinkExhausted,
+		},
+		{
+			name:         "probe of exhausted link with fallback",
+			maxClicks:    1,
+			fallbackURL:  fallback,
+			userAgent:    botUA,
+			wantTarget:   fallback,
+			wantFallback: true,
+		},
+		{
+			name:       "probe of unlimited link",
+			head:       true,
+			userAgent:  browserUA,
+			wantTarget: target,
+			wantProbe:  true,
+		},
+		{
+			name:      "preview of exhausted link",
+			maxClicks: 1,
+			preview:   true,
+			userAgent: browserUA,
+			wantErr:   ErrLinkExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubURLRepository{
+				link: domain.URL{
+					ID:           1,
+					Alias:        "abc123",
+					OriginalURL:  target,
+					RedirectType: domain.RedirectTemporary,
+					MaxClicks:    tt.maxClicks,
+					FallbackURL:  tt.fallbackURL,
+				},
+				clicksLeft: tt.clicksLeft,
+			}
+			u := NewURLUsecase(repo, missCache{}, nil, SigningOptions{}, PasswordOptions{}, PreviewOptions{}, &zlog.Zerolog{})
+
+			redirect, err := u.GetOriginalURL(context.Background(), domain.RedirectRequest{
+				Alias:     "abc123",
+				Query:     url.Values{},
+				UserAgent: tt.userAgent,
+				Head:      tt.head,
+				Preview:   tt.preview,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetOriginalURL() error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("GetOriginalURL() error = %v", err)
+				}
+				if redirect.Target != tt.wantTarget {
+					t.Errorf("Target = %q, want %q", redirect.Target, tt.wantTarget)
+				}
+				if redirect.Fallback != tt.wantFallback {
+					t.Errorf("Fallback = %v, want %v", redirect.Fallback, tt.wantFallback)
+				}
+				if redirect.Probe != tt.wantProbe {
+					t.Errorf("Probe = %v, want %v", redirect.Probe, tt.wantProbe)
+				}
+			}
+			if repo.consumed != tt.wantConsumed {
+				t.Errorf("consumed %d clicks, want %d", repo.consumed, tt.wantConsumed)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"url-shortener-wb/internal/domain"
+)
+
+const maxUTMValueLength = 255
+
+func normalizeUTM(utm domain.UTM) domain.UTM {
+	return domain.UTM{
+		Source:   strings.TrimSpace(utm.Source),
+		Medium:   strings.TrimSpace(utm.Medium),
+		Campaign: strings.TrimSpace(utm.Campaign),
+		Term:     strings.TrimSpace(utm.Term),
+		Content:  strings.TrimSpace(utm.Content),
+	}
+}
+
+func validateUTM(utm domain.UTM) error {
+	if utm.IsZero() {
+		return nil
+	}
+	if utm.Source == "" || utm.Medium == "" || utm.Campaign == "" {
+		return fmt.Errorf("%w: source, medium and campaign are required", ErrInvalidUTM)
+	}
+	for name, value := range utm.Params() {
+		if len(value) > maxUTMValueLength {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidUTM, name, maxUTMValueLength)
+		}
+		if strings.IndexFunc(value, unicode.IsControl) != -1 {
+			return fmt.Errorf("%w: %s contains control characters", ErrInvalidUTM, name)
+		}
+	}
+	return nil
+}
+
+// applyUTM sets the utm_* parameters on destination, replacing any values
+// already present there.
+func applyUTM(destination string, utm domain.UTM) (string, error) {
+	if utm.IsZero() {
+		return destination, nil
+	}
+
+	target, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	query := target.Query()
+	for name, value := range utm.Params() {
+		query.Set(name, value)
+	}
+	target.RawQuery = query.Encode()
+
+	return target.String(), nil
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+	"url-shortener-wb/internal/logging"
+	"url-shortener-wb/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	maxVariantsPerLink = 10
+	maxVariantWeight   = 1000
+	maxVariantName     = 64
+
+	rotationKeyPrefix = "rotation:"
+)
+
+func normalizeSplit(split domain.Split) domain.Split {
+	if split.Rotation == "" {
+		split.Rotation = domain.DefaultRotation
+	}
+	variants := make([]domain.Variant, len(split.Variants))
+	for i, v := range split.Variants {
+		v.ID = 0
+		v.Name = strings.TrimSpace(v.Name)
+		v.Target = strings.TrimSpace(v.Target)
+		if v.Weight == 0 {
+			v.Weight = 1
+		}
+		variants[i] = v
+	}
+	split.Variants = variants
+	return split
+}
+
+func validateSplit(split domain.Split) error {
+	if !split.Rotation.Valid() {
+		return fmt.Errorf("%w: rotation %q, expected one of weighted, round_robin", ErrInvalidVariant, split.Rotation)
+	}
+	if len(split.Variants) > maxVariantsPerLink {
+		return fmt.Errorf("%w: at most %d variants per link", ErrInvalidVariant, maxVariantsPerLink)
+	}
+	if len(split.Variants) == 1 {
+		return fmt.Errorf("%w: a split needs at least two variants", ErrInvalidVariant)
+	}
+
+	names := make(map[string]bool, len(split.Variants))
+	for i, v := range split.Variants {
+		if v.Name == "" || len(v.Name) > maxVariantName {
+			return fmt.Errorf("%w: variant %d name must be 1-%d characters", ErrInvalidVariant, i, maxVariantName)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("%w: duplicate variant name %q", ErrInvalidVariant, v.Name)
+		}
+		names[v.Name] = true
+		if v.Weight < 1 || v.Weight > maxVariantWeight {
+			return fmt.Errorf("%w: variant %q weight must be between 1 and %d", ErrInvalidVariant, v.Name, maxVariantWeight)
+		}
+		if err := validateURL(v.Target); err != nil {
+			return fmt.Errorf("%w: variant %q target: %v", ErrInvalidVariant, v.Name, err)
+		}
+	}
+	return nil
+}
+
+// chooseVariant picks the variant for a visit. A sticky visitor keeps the
+// variant remembered in req as long as it still exists.
+func (u *urlUsecase) chooseVariant(ctx context.Context, url *domain.URL, req domain.RedirectRequest) *domain.Variant {
+	split := url.Split
+	if !split.Enabled() {
+		return nil
+	}
+
+	if split.Sticky && req.StickyVariant != 0 {
+		if v := split.Variant(req.StickyVariant); v != nil {
+			return v
+		}
+	}
+
+	if split.Rotation == domain.RotationRoundRobin {
+		n, err := u.cache.Incr(ctx, rotationKeyPrefix+url.Alias, 0)
+		if err == nil {
+			return &split.Variants[(n-1)%int64(len(split.Variants))]
+		}
+		// Fall back to a uniform pick rather than failing the redirect.
+		logging.FromContext(ctx, u.logger).Warn().Err(err).Str("alias", url.Alias).Msg("round robin counter unavailable")
+		return &split.Variants[rand.IntN(len(split.Variants))]
+	}
+
+	total := 0
+	for _, v := range split.Variants {
+		total += v.Weight
+	}
+	pick := rand.IntN(total)
+	for i := range split.Variants {
+		pick -= split.Variants[i].Weight
+		if pick < 0 {
+			return &split.Variants[i]
+		}
+	}
+	return &split.Variants[len(split.Variants)-1]
+}
+
+func (u *urlUsecase) GetSplit(ctx context.Context, alias string) (_ *domain.Split, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.GetSplit", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := u.lookupURL(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	return &url.Split, nil
+}
+
+func (u *urlUsecase) ReplaceSplit(ctx context.Context, alias string, split domain.Split) (_ *domain.Split, err error) {
+	ctx, span := tracer.Start(ctx, "urlUsecase.ReplaceSplit", trace.WithAttributes(
+		attribute.String("url.alias", alias),
+		attribute.Int("variants.count", len(split.Variants)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	split = normalizeSplit(split)
+	if err := validateSplit(split); err != nil {
+		return nil, err
+	}
+
+	url, err := u.urlRepo.GetByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: url not found for alias %s", ErrNotFound, alias)
+		}
+		return nil, fmt.Errorf("failed to get url by alias: %w", err)
+	}
+
+	if err := u.urlRepo.ReplaceSplit(ctx, url.ID, &split); err != nil {
+		return nil, fmt.Errorf("failed to replace split: %w", err)
+	}
+
+	u.invalidateURL(ctx, alias)
+
+	return &split, nil
+}
@@ -0,0 +1,76 @@
+package useragent
+
+import (
+	"strings"
+
+	"url-shortener-wb/internal/domain"
+)
+
+// Parse classifies a User-Agent header into the OS and device class used by
+// redirect rules. Unknown values are returned as empty strings.
+func Parse(ua string) domain.Visitor {
+	s := strings.ToLower(ua)
+
+	var v domain.Visitor
+	switch {
+	case strings.Contains(s, "iphone") || strings.Contains(s, "ipod"):
+		v.OS, v.Device = domain.OSIOS, domain.DeviceMobile
+	case strings.Contains(s, "ipad"):
+		v.OS, v.Device = domain.OSIOS, domain.DeviceTablet
+	case strings.Contains(s, "android"):
+		v.OS = domain.OSAndroid
+		if strings.Contains(s, "mobile") {
+			v.Device = domain.DeviceMobile
+		} else {
+			v.Device = domain.DeviceTablet
+		}
+	case strings.Contains(s, "windows phone"):
+		v.OS, v.Device = domain.OSWindows, domain.DeviceMobile
+	case strings.Contains(s, "windows"):
+		v.OS, v.Device = domain.OSWindows, domain.DeviceDesktop
+	case strings.Contains(s, "cros"):
+		v.OS, v.Device = domain.OSChromeOS, domain.DeviceDesktop
+	case strings.Contains(s, "macintosh") || strings.Contains(s, "mac os x"):
+		v.OS, v.Device = domain.OSMacOS, domain.DeviceDesktop
+	case strings.Contains(s, "linux"):
+		v.OS, v.Device = domain.OSLinux, domain.DeviceDesktop
+	}
+
+	if v.Device == "" && (strings.Contains(s, "mobi") || strings.Contains(s, "phone")) {
+		v.Device = domain.DeviceMobile
+	}
+
+	return v
+}
+
+// socialCrawlers are User-Agent fragments of the bots that fetch a link to
+// build its preview card in messengers and social networks.
+var socialCrawlers = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"telegrambot",
+	"vkshare",
+	"whatsapp",
+	"slackbot",
+	"discordbot",
+	"linkedinbot",
+	"skypeuripreview",
+	"viber",
+	"pinterest",
+	"redditbot",
+	"odklbot",
+	"embedly",
+	"iframely",
+}
+
+// IsSocialCrawler reports whether ua belongs to a link preview bot.
+func IsSocialCrawler(ua string) bool {
+	s := strings.ToLower(ua)
+	for _, bot := range socialCrawlers {
+		if strings.Contains(s, bot) {
+			return true
+		}
+	}
+	return false
+}
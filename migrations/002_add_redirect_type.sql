@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_type VARCHAR(8) NOT NULL DEFAULT '307';
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN IF EXISTS redirect_type;
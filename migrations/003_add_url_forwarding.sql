@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS forward_query BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS forward_path BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS query_merge VARCHAR(16) NOT NULL DEFAULT 'request';
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN IF EXISTS query_merge;
+ALTER TABLE urls DROP COLUMN IF EXISTS forward_path;
+ALTER TABLE urls DROP COLUMN IF EXISTS forward_query;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_source VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_medium VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_term VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_content VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_urls_utm_campaign ON urls(utm_campaign) WHERE utm_campaign <> '';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_urls_utm_campaign;
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_content;
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_term;
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_campaign;
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_medium;
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_source;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS url_rules (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    os VARCHAR(32) NOT NULL DEFAULT '',
+    device VARCHAR(32) NOT NULL DEFAULT '',
+    target TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_rules_url_id ON url_rules(url_id, priority);
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS matched_rule VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE clicks DROP COLUMN IF EXISTS matched_rule;
+DROP TABLE IF EXISTS url_rules;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE url_rules ADD COLUMN IF NOT EXISTS country CHAR(2) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE url_rules DROP COLUMN IF EXISTS country;
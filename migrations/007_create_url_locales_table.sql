@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS url_locales (
+    url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    target TEXT NOT NULL,
+    PRIMARY KEY (url_id, locale)
+);
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS locale VARCHAR(35) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE clicks DROP COLUMN IF EXISTS locale;
+DROP TABLE IF EXISTS url_locales;
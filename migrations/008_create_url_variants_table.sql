@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS rotation VARCHAR(16) NOT NULL DEFAULT 'weighted';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS sticky_variants BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS url_variants (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    target TEXT NOT NULL,
+    weight INTEGER NOT NULL DEFAULT 1,
+    UNIQUE (url_id, name)
+);
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS variant VARCHAR(64) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE clicks DROP COLUMN IF EXISTS variant;
+DROP TABLE IF EXISTS url_variants;
+ALTER TABLE urls DROP COLUMN IF EXISTS sticky_variants;
+ALTER TABLE urls DROP COLUMN IF EXISTS rotation;
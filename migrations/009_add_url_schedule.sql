@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active_from TIMESTAMP WITH TIME ZONE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active_until TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS url_schedule (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    target TEXT NOT NULL,
+    UNIQUE (url_id, starts_at)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS url_schedule;
+ALTER TABLE urls DROP COLUMN IF EXISTS active_until;
+ALTER TABLE urls DROP COLUMN IF EXISTS active_from;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS clicks_used INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS fallback_url TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN IF EXISTS fallback_url;
+ALTER TABLE urls DROP COLUMN IF EXISTS clicks_used;
+ALTER TABLE urls DROP COLUMN IF EXISTS max_clicks;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS target_down BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS target_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS target_checked_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_urls_target_check ON urls(target_checked_at NULLS FIRST)
+    WHERE fallback_url <> '' AND NOT disabled;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_urls_target_check;
+ALTER TABLE urls DROP COLUMN IF EXISTS target_checked_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS target_failures;
+ALTER TABLE urls DROP COLUMN IF EXISTS target_down;
+ALTER TABLE urls DROP COLUMN IF EXISTS disabled;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS og_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS og_description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS og_image TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN IF EXISTS og_image;
+ALTER TABLE urls DROP COLUMN IF EXISTS og_description;
+ALTER TABLE urls DROP COLUMN IF EXISTS og_title;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS channel VARCHAR(16) NOT NULL DEFAULT 'link';
+
+-- +goose Down
+ALTER TABLE clicks DROP COLUMN IF EXISTS channel;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS owner VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS click_count BIGINT NOT NULL DEFAULT 0;
+
+UPDATE urls SET click_count = c.total
+FROM (SELECT url_id, COUNT(*) AS total FROM clicks GROUP BY url_id) c
+WHERE c.url_id = urls.id;
+
+CREATE INDEX IF NOT EXISTS idx_urls_owner ON urls(owner);
+CREATE INDEX IF NOT EXISTS idx_urls_created_at_id ON urls(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_urls_click_count_id ON urls(click_count, id);
+CREATE INDEX IF NOT EXISTS idx_urls_alias_trgm ON urls USING gin (alias gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_urls_original_url_trgm ON urls USING gin (original_url gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_urls_original_url_trgm;
+DROP INDEX IF EXISTS idx_urls_alias_trgm;
+DROP INDEX IF EXISTS idx_urls_click_count_id;
+DROP INDEX IF EXISTS idx_urls_created_at_id;
+DROP INDEX IF EXISTS idx_urls_owner;
+ALTER TABLE urls DROP COLUMN IF EXISTS click_count;
+ALTER TABLE urls DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS folders (
+    id SERIAL PRIMARY KEY,
+    parent_id INTEGER REFERENCES folders(id),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE NULLS NOT DISTINCT (parent_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_folders_parent_id ON folders(parent_id);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS folder_id INTEGER REFERENCES folders(id);
+
+CREATE INDEX IF NOT EXISTS idx_urls_folder_id ON urls(folder_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_urls_folder_id;
+ALTER TABLE urls DROP COLUMN IF EXISTS folder_id;
+DROP TABLE IF EXISTS folders;
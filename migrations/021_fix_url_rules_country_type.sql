@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE url_rules ALTER COLUMN country TYPE VARCHAR(2) USING trim(country);
+
+-- +goose Down
+ALTER TABLE url_rules ALTER COLUMN country TYPE CHAR(2);
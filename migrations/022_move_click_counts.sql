@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS url_click_counts (
+    url_id INTEGER PRIMARY KEY REFERENCES urls(id) ON DELETE CASCADE,
+    clicks BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO url_click_counts (url_id, clicks)
+SELECT id, click_count FROM urls
+ON CONFLICT (url_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_url_click_counts_clicks_url_id ON url_click_counts(clicks, url_id);
+
+DROP INDEX IF EXISTS idx_urls_click_count_id;
+ALTER TABLE urls DROP COLUMN IF EXISTS click_count;
+
+-- +goose Down
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS click_count BIGINT NOT NULL DEFAULT 0;
+
+UPDATE urls SET click_count = c.clicks
+FROM url_click_counts c
+WHERE c.url_id = urls.id;
+
+CREATE INDEX IF NOT EXISTS idx_urls_click_count_id ON urls(click_count, id);
+
+DROP TABLE IF EXISTS url_click_counts;
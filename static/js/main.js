@@ -9,6 +9,7 @@ document.addEventListener('DOMContentLoaded', function() {
     shortenBtn.addEventListener('click', async function() {
         const originalUrl = document.getElementById('originalUrl').value.trim();
         const customAlias = document.getElementById('customAlias').value.trim();
+        const redirectType = document.getElementById('redirectType').value;
         
         // Валидация URL
         if (!originalUrl) {
@@ -36,7 +37,8 @@ document.addEventListener('DOMContentLoaded', function() {
                 },
                 body: JSON.stringify({
                     url: originalUrl,
-                    custom: customAlias || ''
+                    custom: customAlias || '',
+                    redirect_type: redirectType
                 })
             });
 